@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/go-git/go-git/v5"
+	zeroconfig "zero-workflow/src/internal/config"
+	"zero-workflow/src/internal/redact"
+	"zero-workflow/src/pkg/ai/zai"
+)
+
+// chatCompletion sends a single prompt to the AI provider and returns the full response.
+// Commands that generate text (commit messages, PR descriptions, ...) share it.
+func chatCompletion(prompt string) (string, error) {
+	token, err := zeroconfig.GetToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to get AI token: %w", err)
+	}
+
+	client, err := zai.NewClient(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to create AI client: %w", err)
+	}
+
+	// Commands redact their prompt before starting a spinner; this catches the
+	// rest and finds nothing new in a prompt that is already redacted
+	prompt, err = redactPrompt(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := client.Chat(context.Background(), prompt)
+	if err != nil {
+		return "", fmt.Errorf("AI generation failed: %w", err)
+	}
+	return response, nil
+}
+
+// redactPrompt removes secrets from a prompt before it leaves the machine and
+// warns about each one, see loadRedactor
+func redactPrompt(prompt string) (string, error) {
+	redactor, err := loadRedactor()
+	if err != nil {
+		return "", err
+	}
+
+	redacted, findings := redactor.Redact(prompt)
+	reportRedactions(findings)
+	return redacted, nil
+}
+
+// loadRedactor returns the redactor configured by the [redact] section of the
+// repository, or the default one outside a repository. Everything sent out,
+// prompts and texts for embeddings alike, goes through it.
+func loadRedactor() (*redact.Redactor, error) {
+	repoConfig := zeroconfig.DefaultRepoConfig()
+	if repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true}); err == nil {
+		if worktree, err := repo.Worktree(); err == nil {
+			if repoConfig, err = zeroconfig.LoadRepoConfig(worktree.Filesystem.Root()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	redactor, err := redact.New(repoConfig.Redact)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", zeroconfig.RepoConfigPath, err)
+	}
+	return redactor, nil
+}
+
+// reportRedactions warns on stderr about secrets that were redacted
+func reportRedactions(findings []redact.Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	warn := color.New(color.FgYellow)
+	warn.Fprintf(os.Stderr, "Warning: redacted %d secret(s) before sending:\n", len(findings))
+	for _, finding := range findings {
+		warn.Fprintf(os.Stderr, "  - %s: %s\n", finding.Rule, finding.Preview)
+	}
+}
@@ -4,20 +4,34 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 
+	"github.com/fatih/color"
+	"github.com/go-git/go-git/v5"
 	"github.com/spf13/cobra"
 	"zero-workflow/src/internal/config"
 	"zero-workflow/src/internal/files"
 	"zero-workflow/src/internal/handlers"
+	"zero-workflow/src/internal/index"
 	"zero-workflow/src/internal/renderer"
+	"zero-workflow/src/internal/repoinfo"
+	"zero-workflow/src/internal/tokens"
 	"zero-workflow/src/pkg/ai/zai"
+	"zero-workflow/src/pkg/types"
 )
 
 var (
 	interactive bool
 	fileList   []string
+	stdinName   string
+	execCommand string
+	askRepo     bool
+	askRAG      bool
+	askTopK     int
 )
 
 var askCmd = &cobra.Command{
@@ -30,6 +44,14 @@ Examples:
   zw ask "How to create a Go struct?"
   zw ask "Explain this code" --file src/main.go
   zw ask "Review my code" -f main.go -f config.go
+  zw ask "How is this package organized?" -f ./pkg/...
+  zw ask "Find unused helpers" -f 'src/**/*.go'
+  zw ask "Why is this loop slow?" -f main.go:40-90
+  zw ask "Simplify this function" -f cmd/commit.go#runCommit
+  go test ./... 2>&1 | zw ask "Why does this fail?"
+  zw ask "Why does this fail?" --exec "go test ./..." -f main.go
+  zw ask "Where should I put a retry helper?" --repo
+  zw ask "How are tokens refreshed?" --rag  # after zw index
   zw ask -i  # Interactive mode`,
 	Args: cobra.ArbitraryArgs,
 	Run:  runAsk,
@@ -38,7 +60,12 @@ Examples:
 func init() {
 	rootCmd.AddCommand(askCmd)
 	askCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactive mode for continuous conversation")
-	askCmd.Flags().StringSliceVarP(&fileList, "file", "f", []string{}, "Include files for context (can be used multiple times)")
+	askCmd.Flags().StringSliceVarP(&fileList, "file", "f", []string{}, "Include files, directories, globs such as 'src/**/*.go', line ranges (main.go:40-90) or Go symbols (main.go#run) for context (can be used multiple times)")
+	askCmd.Flags().StringVar(&stdinName, "stdin-name", "stdin", "Label for input piped to zw, e.g. test-output.log")
+	askCmd.Flags().StringVar(&execCommand, "exec", "", "Run a shell command and include its output and exit code for context")
+	askCmd.Flags().BoolVar(&askRepo, "repo", false, "Include a summary of the repository: file tree, branch, recent commits, status and diffs")
+	askCmd.Flags().BoolVar(&askRAG, "rag", false, "Include the code most relevant to the question, found in the index built by zw index")
+	askCmd.Flags().IntVar(&askTopK, "top-k", 8, "Number of chunks --rag includes")
 }
 
 func runAsk(cmd *cobra.Command, args []string) {
@@ -65,29 +92,42 @@ func runAsk(cmd *cobra.Command, args []string) {
 		errorHandler.HandleFatalError(fmt.Errorf("please provide a question or use -i for interactive mode"), "argument validation")
 	}
 
-	question := strings.Join(args, " ")
-	askQuestion(client, renderer, question, fileList, errorHandler)
+	// The question is redacted first, as --rag sends it for embeddings
+	question, err := redactPrompt(strings.Join(args, " "))
+	if err != nil {
+		errorHandler.HandleFatalError(err, "question processing")
+	}
+
+	inputs, err := readInputs(question)
+	if err != nil {
+		errorHandler.HandleFatalError(err, "input reading")
+	}
+
+	askQuestion(client, renderer, question, fileList, inputs, errorHandler)
 }
 
-func askQuestion(client *zai.Client, renderer *renderer.MarkdownRenderer, question string, filePaths []string, errorHandler *handlers.ErrorHandler) {
+func askQuestion(client *zai.Client, renderer *renderer.MarkdownRenderer, question string, filePaths []string, inputs []files.FileContent, errorHandler *handlers.ErrorHandler) {
+	// Process files before the spinner starts so the file listing is not overdrawn
+	fileContext, images, err := processFiles(question, filePaths, inputs, errorHandler)
+	if err != nil {
+		errorHandler.HandleFatalError(err, "question processing")
+	}
+
+	// Redact before the spinner starts so the warning is not overdrawn
+	fullQuestion, err := redactPrompt(question + fileContext)
+	if err != nil {
+		errorHandler.HandleFatalError(err, "question processing")
+	}
+
 	spinnerHandler := handlers.NewSpinnerHandler("Thinking")
 	var response string
-	var err error
 
 	err = spinnerHandler.WithSpinner(func() error {
-		// Process files if provided
-		fileContext, processErr := processFiles(filePaths, errorHandler)
-		if processErr != nil {
-			return processErr
-		}
-
-		// Combine question with file context
-		fullQuestion := question + fileContext
-
 		ctx := context.Background()
 		// The callback is now empty, as we are not printing live deltas.
 		// The final complete response is returned by ChatStream.
-		response, err = client.ChatStream(ctx, fullQuestion, func(delta string) {
+		parts := append([]types.Part{types.TextPart(fullQuestion)}, images...)
+		response, err = client.ChatStreamParts(ctx, parts, func(delta string) {
 			// Do nothing here to prevent raw output
 		})
 		if err != nil {
@@ -107,25 +147,335 @@ func askQuestion(client *zai.Client, renderer *renderer.MarkdownRenderer, questi
 	}
 }
 
-// processFiles handles file processing logic
-func processFiles(filePaths []string, errorHandler *handlers.ErrorHandler) (string, error) {
-	if len(filePaths) == 0 {
-		return "", nil
+// processFiles handles file processing logic. The files are packed into what is
+// left of the model's context window together with piped input and command
+// output, preferring those relevant to the question. Images are returned as
+// message parts; models that do not accept images get a placeholder instead.
+func processFiles(question string, filePaths []string, inputs []files.FileContent, errorHandler *handlers.ErrorHandler) (string, []types.Part, error) {
+	if len(filePaths) == 0 && len(inputs) == 0 {
+		return "", nil, nil
+	}
+
+	policy, err := files.LoadPolicy()
+	if err != nil {
+		return "", nil, err
+	}
+	fileReader := files.NewReaderWithPolicy(policy)
+	contents := append([]files.FileContent(nil), inputs...)
+	if len(filePaths) > 0 {
+		fileContents, err := readFileList(fileReader, filePaths)
+		if err != nil {
+			return "", nil, err
+		}
+		contents = append(contents, fileContents...)
+	}
+
+	cfg := config.DefaultConfig()
+	model := tokens.ForModel(cfg.Provider, cfg.Model)
+	if !model.Vision {
+		dropImages(contents, model.Name)
 	}
 
-	fileReader := files.NewReader()
+	budget := model.Budget(question, config.DefaultAIParams().MaxTokens)
+	packing := fileReader.Pack(contents, question, budget, model.Estimate)
+	if len(packing.Files) == 0 {
+		return "", nil, fmt.Errorf("no file fits into the context window of %s (%d tokens)", model.Name, model.ContextWindow)
+	}
 
-	if err := fileReader.ValidateFiles(filePaths); err != nil {
-		return "", fmt.Errorf("file validation error: %w", err)
+	var images []types.Part
+	for _, file := range packing.Files {
+		if file.Image != nil {
+			images = append(images, types.ImagePart(file.MIME, file.Image))
+		}
 	}
 
-	fileContents, err := fileReader.ReadFiles(filePaths)
+	fmt.Printf("\n[*] Loaded %s for context (~%d of %d tokens)\n", describeContext(packing.Files), packing.Tokens, packing.Budget)
+	printTrimmed(packing.Trimmed)
+	return fileReader.FormatFilesForAI(packing.Files), images, nil
+}
+
+// dropImages keeps only the placeholders of images for a model that does not
+// accept them, and warns about it
+func dropImages(contents []files.FileContent, model string) {
+	var dropped []string
+	for i := range contents {
+		if contents[i].Image != nil {
+			contents[i].Image = nil
+			dropped = append(dropped, contents[i].Path)
+		}
+	}
+	if len(dropped) > 0 {
+		fmt.Println(color.YellowString("Warning: %s does not accept images, sending only the names of %s; set ZW_VISION=true if it does", model, strings.Join(dropped, ", ")))
+	}
+}
+
+// readFileList expands, validates and reads the --file arguments
+func readFileList(fileReader *files.Reader, filePaths []string) ([]files.FileContent, error) {
+	// Expand directories, "./pkg/..." and globs
+	expansion, err := fileReader.Expand(filePaths)
+	if err != nil {
+		return nil, fmt.Errorf("file validation error: %w", err)
+	}
+	if expansion.Expanded {
+		printExpansion(expansion)
+	}
+	if len(expansion.Files) == 0 {
+		return nil, fmt.Errorf("no files matched %s", strings.Join(filePaths, ", "))
+	}
+
+	if err := fileReader.ValidateFiles(expansion.Files); err != nil {
+		return nil, fmt.Errorf("file validation error: %w", err)
+	}
+
+	fileContents, err := fileReader.ReadFiles(expansion.Files)
 	if err != nil {
-		return "", fmt.Errorf("error reading files: %w", err)
+		return nil, fmt.Errorf("error reading files: %w", err)
+	}
+	return fileContents, nil
+}
+
+// describeContext summarizes what is sent, e.g. "2 file(s), stdin"
+func describeContext(contents []files.FileContent) string {
+	var parts []string
+	fileCount := 0
+	for _, content := range contents {
+		switch content.Source {
+		case files.SourceStdin:
+			parts = append(parts, content.Path)
+		case files.SourceCommand:
+			parts = append(parts, "output of "+content.Path)
+		case files.SourceRepo:
+			parts = append(parts, "repository summary")
+		default:
+			fileCount++
+		}
+	}
+	if fileCount > 0 {
+		parts = append([]string{fmt.Sprintf("%d file(s)", fileCount)}, parts...)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printTrimmed lists the files that did not fit whole into the context window
+func printTrimmed(trimmed []files.Trim) {
+	if len(trimmed) == 0 {
+		return
+	}
+	fmt.Printf("[*] Trimmed %d file(s) to fit the context window:\n", len(trimmed))
+	for _, trim := range trimmed {
+		switch trim.How {
+		case files.TrimOutline:
+			fmt.Println(color.New(color.Faint).Sprintf("    %s (outline, ~%d of %d tokens)", trim.Path, trim.Sent, trim.Tokens))
+		case files.TrimTail:
+			fmt.Println(color.New(color.Faint).Sprintf("    %s (last ~%d of %d tokens)", trim.Path, trim.Sent, trim.Tokens))
+		default:
+			fmt.Println(color.New(color.Faint).Sprintf("    %s (omitted, ~%d tokens)", trim.Path, trim.Tokens))
+		}
+	}
+}
+
+// readInputs collects the repository summary, chunks retrieved from the index,
+// input piped to zw and the output of --exec as context
+func readInputs(question string) ([]files.FileContent, error) {
+	reader := files.NewReader()
+	var inputs []files.FileContent
+
+	if askRepo {
+		input, err := repoSummary()
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, *input)
+	}
+
+	if askRAG {
+		chunks, err := retrieveChunks(question)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, chunks...)
 	}
 
-	fmt.Printf("\n[*] Loaded %d file(s) for context\n", len(fileContents))
-	return fileReader.FormatFilesForAI(fileContents), nil
+	if stdinPiped() {
+		input, err := reader.ReadInput(os.Stdin, stdinName, files.SourceStdin)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(input.Content) != "" {
+			inputs = append(inputs, *input)
+		}
+	}
+
+	if execCommand != "" {
+		input, err := runForContext(reader, execCommand)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, *input)
+	}
+
+	return inputs, nil
+}
+
+// repoSummary describes the repository containing the current directory
+func repoSummary() (*files.FileContent, error) {
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("--repo needs a git repository: %w", err)
+	}
+
+	summary, err := repoinfo.Summarize(repo)
+	if err != nil {
+		return nil, err
+	}
+	content := summary.Format()
+	return &files.FileContent{
+		Path:    summary.Name,
+		Content: content,
+		Size:    int64(len(content)),
+		Source:  files.SourceRepo,
+	}, nil
+}
+
+// retrieveChunks searches the index for the chunks most relevant to question and
+// returns them as excerpts of their files
+func retrieveChunks(question string) ([]files.FileContent, error) {
+	if askTopK < 1 {
+		return nil, fmt.Errorf("--top-k must be at least 1")
+	}
+
+	_, root, err := openRepoRoot()
+	if err != nil {
+		return nil, err
+	}
+	ix, err := index.Load(root)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no index found, run zw index first")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Semantic search needs the question embedded with the model of the index
+	var queryEmbedding []float32
+	if embedder := configuredEmbedder(); embedder != nil && embedder.Model == ix.EmbeddingModel {
+		embeddings, err := embedder.Embed(context.Background(), []string{question})
+		if err != nil {
+			fmt.Println(color.YellowString("Warning: semantic search unavailable, using keyword search: %v", err))
+		} else {
+			queryEmbedding = embeddings[0]
+		}
+	}
+
+	results := ix.Search(question, askTopK, queryEmbedding)
+	if len(results) == 0 {
+		return nil, fmt.Errorf("nothing in the index matches the question, try --file instead")
+	}
+
+	// The index may predate changes to the [files] policy
+	policy, err := files.LoadPolicy()
+	if err != nil {
+		return nil, err
+	}
+	allowed := results[:0]
+	for _, result := range results {
+		if policy.Check(filepath.Join(root, filepath.FromSlash(result.Chunk.Path))) == nil {
+			allowed = append(allowed, result)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("the files policy refuses every file that matches the question, run zw index again")
+	}
+	results = allowed
+
+	fmt.Printf("\n[*] Retrieved %d chunk(s) from the index:\n", len(results))
+	chunks := make([]files.FileContent, 0, len(results))
+	for _, result := range results {
+		chunk := result.Chunk
+		label := fmt.Sprintf("%s:%d-%d", chunk.Path, chunk.Start, chunk.End)
+		if chunk.Symbol != "" {
+			label += " (" + chunk.Symbol + ")"
+		}
+		fmt.Println(color.New(color.Faint).Sprintf("    %s", label))
+
+		chunks = append(chunks, files.FileContent{
+			Path:    chunk.Path,
+			Content: chunk.Text,
+			Size:    int64(len(chunk.Text)),
+			Start:   chunk.Start,
+			End:     chunk.End,
+			Symbol:  chunk.Symbol,
+		})
+	}
+	return chunks, nil
+}
+
+// stdinPiped reports whether stdin is a pipe or a redirected file rather than a
+// terminal or /dev/null
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeNamedPipe != 0 || info.Mode().IsRegular()
+}
+
+// runForContext runs command with the shell and captures its combined output
+// and exit code. A failing command is not an error: its output is usually what
+// the question is about.
+func runForContext(reader *files.Reader, command string) (*files.FileContent, error) {
+	fmt.Printf("[*] Running: %s\n", command)
+
+	outputReader, outputWriter := io.Pipe()
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = outputWriter
+	cmd.Stderr = outputWriter
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %q: %w", command, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		outputWriter.Close()
+		close(done)
+	}()
+
+	input, err := reader.ReadInput(outputReader, command, files.SourceCommand)
+	outputReader.Close()
+	<-done
+	if err != nil {
+		return nil, err
+	}
+
+	input.ExitCode = cmd.ProcessState.ExitCode()
+	fmt.Printf("[*] Command exited with code %d\n", input.ExitCode)
+	return input, nil
+}
+
+// maxListedFiles limits how many included files are listed after expansion
+const maxListedFiles = 20
+
+// printExpansion lists the files picked up from directories and patterns, and
+// what was left out
+func printExpansion(expansion *files.Expansion) {
+	if len(expansion.Files) > 0 {
+		fmt.Printf("\n[*] Included %d file(s):\n", len(expansion.Files))
+	}
+	for i, path := range expansion.Files {
+		if i == maxListedFiles {
+			fmt.Printf("    ... and %d more\n", len(expansion.Files)-maxListedFiles)
+			break
+		}
+		fmt.Printf("    %s\n", path)
+	}
+
+	if len(expansion.Excluded) > 0 {
+		fmt.Printf("\n[*] Excluded %d:\n", len(expansion.Excluded))
+		for _, excluded := range expansion.Excluded {
+			fmt.Println(color.New(color.Faint).Sprintf("    %s (%s)", excluded.Path, excluded.Reason))
+		}
+	}
 }
 
 func runInteractiveMode(client *zai.Client, renderer *renderer.MarkdownRenderer, errorHandler *handlers.ErrorHandler) {
@@ -154,7 +504,7 @@ func runInteractiveMode(client *zai.Client, renderer *renderer.MarkdownRenderer,
 		}
 		
 		// In interactive mode, files are not supported yet
-		askQuestion(client, renderer, input, []string{}, errorHandler)
+		askQuestion(client, renderer, input, []string{}, nil, errorHandler)
 	}
 	
 	if err := scanner.Err(); err != nil {
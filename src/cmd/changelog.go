@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"zero-workflow/src/internal/changelog"
+	"zero-workflow/src/internal/gitutil"
+	"zero-workflow/src/internal/handlers"
+)
+
+var (
+	changelogFrom    string
+	changelogTo      string
+	changelogVersion string
+	changelogFile    string
+	changelogWrite   bool
+	changelogAI      bool
+	changelogLang    string
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Generate a changelog section from commit history",
+	Long: `Collects the commits between two revisions, groups Conventional Commits into
+Keep a Changelog sections (Added, Changed, Deprecated, Removed, Fixed, Security)
+and prints the section or updates CHANGELOG.md. With --ai the entries are
+rewritten into user-facing release notes.
+
+Without --from the nearest tag before --to is used. The section is named after
+the tag at --to, or "Unreleased" when --to is not tagged.
+
+Examples:
+  zw changelog
+  zw changelog --from v1.0.0 --to v1.1.0 --write
+  zw changelog --ai --lang ru`,
+	Args: cobra.NoArgs,
+	RunE: runChangelog,
+}
+
+func init() {
+	rootCmd.AddCommand(changelogCmd)
+	changelogCmd.Flags().StringVar(&changelogFrom, "from", "", "Start after this revision (default: nearest tag before --to)")
+	changelogCmd.Flags().StringVar(&changelogTo, "to", "HEAD", "End at this revision")
+	changelogCmd.Flags().StringVar(&changelogVersion, "version", "", "Section name (default: tag at --to, or Unreleased)")
+	changelogCmd.Flags().StringVar(&changelogFile, "file", "CHANGELOG.md", "Changelog file, relative to the repository root")
+	changelogCmd.Flags().BoolVarP(&changelogWrite, "write", "w", false, "Insert or replace the section in the changelog file instead of printing it")
+	changelogCmd.Flags().BoolVar(&changelogAI, "ai", false, "Rewrite the entries into user-facing release notes with AI")
+	changelogCmd.Flags().StringVarP(&changelogLang, "lang", "l", "en", "Language of AI release notes (ru, en, uk, kz)")
+}
+
+func runChangelog(cmd *cobra.Command, args []string) error {
+	if changelogAI && !isValidLanguage(changelogLang) {
+		return fmt.Errorf("unsupported language: %s. Supported: ru, en, uk, kz", changelogLang)
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return fmt.Errorf("not a git repository: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	to, err := resolveCommit(repo, changelogTo)
+	if err != nil {
+		return err
+	}
+
+	fromName := changelogFrom
+	var from *object.Commit
+	if changelogFrom != "" {
+		if from, err = resolveCommit(repo, changelogFrom); err != nil {
+			return err
+		}
+	} else if fromName, from, err = gitutil.NearestTag(repo, to, nil); err != nil {
+		return fmt.Errorf("failed to look up tags: %w", err)
+	}
+
+	commits, err := gitutil.CommitRange(from, to)
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	release := &changelog.Release{Version: changelogVersion}
+	if release.Version == "" {
+		if release.Version, err = tagVersion(repo, changelogTo, to); err != nil {
+			return err
+		}
+	}
+	release.Version = changelog.VersionFromTag(release.Version)
+	if release.Version != changelog.Unreleased {
+		release.Date = to.Committer.When
+	}
+
+	for _, commit := range commits {
+		if commit.NumParents() > 1 {
+			continue
+		}
+		if entry, ok := changelog.Classify(commit.Message, commit.Hash.String()[:7]); ok {
+			release.Entries = append(release.Entries, entry)
+		}
+	}
+
+	if fromName == "" {
+		fromName = "the first commit"
+	}
+	fmt.Fprintf(os.Stderr, "%d commits since %s, %d changelog entries\n", len(commits), fromName, len(release.Entries))
+
+	body := release.Body()
+	if changelogAI && len(release.Entries) > 0 {
+		if body, err = rewriteReleaseNotes(body); err != nil {
+			return err
+		}
+	}
+	section := release.Render(body)
+
+	if !changelogWrite {
+		fmt.Print(section)
+		return nil
+	}
+
+	path := changelogFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(worktree.Filesystem.Root(), path)
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", changelogFile, err)
+	}
+	if err := os.WriteFile(path, []byte(changelog.Update(string(existing), section, release.Version)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", changelogFile, err)
+	}
+
+	color.Green("✓ Updated %s: %s", changelogFile, release.Heading())
+	return nil
+}
+
+// resolveCommit resolves a revision to a commit, peeling annotated tags
+func resolveCommit(repo *git.Repository, rev string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %q: %w", rev, err)
+	}
+	if tag, err := repo.TagObject(*hash); err == nil {
+		return tag.Commit()
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", rev, err)
+	}
+	return commit, nil
+}
+
+// tagVersion names the section after rev when it is a tag, or after a tag on its
+// commit; otherwise the changes are unreleased
+func tagVersion(repo *git.Repository, rev string, commit *object.Commit) (string, error) {
+	if _, err := repo.Tag(rev); err == nil {
+		return rev, nil
+	}
+
+	tags, err := gitutil.TagsByCommit(repo)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up tags: %w", err)
+	}
+	if names := tags[commit.Hash]; len(names) > 0 {
+		return names[len(names)-1], nil
+	}
+	return changelog.Unreleased, nil
+}
+
+// rewriteReleaseNotes asks the model to turn commit-based entries into release notes
+func rewriteReleaseNotes(entries string) (string, error) {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Write the release notes in %s, but keep the section headings in English.\n\n", languageName(changelogLang)))
+	builder.WriteString("Rewrite the following changelog entries, generated from commit messages, into release notes for the users of the project.\n\n")
+	builder.WriteString("Entries:\n" + entries + "\n\n")
+
+	requirements := []string{
+		"Use only the Keep a Changelog sections that appear in the entries (### Added, ### Changed, ### Deprecated, ### Removed, ### Fixed, ### Security), in that order",
+		"Describe what changed for users, not how the code changed; drop purely internal entries",
+		"Merge entries that describe the same change",
+		"Keep **BREAKING:** markers and say what users need to do",
+		"Write one bullet per change",
+		"Do not invent changes that are not in the entries",
+	}
+	builder.WriteString("Requirements:\n")
+	for i, requirement := range requirements {
+		builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, requirement))
+	}
+	builder.WriteString("\nReturn only the Markdown sections, without a version heading.")
+
+	// Redact before the spinner starts so the warning is not overdrawn
+	prompt, err := redactPrompt(builder.String())
+	if err != nil {
+		return "", err
+	}
+
+	var response string
+	if term.IsTerminal(int(os.Stdout.Fd())) && !changelogWrite {
+		spinnerHandler := handlers.NewSpinnerHandler("Writing release notes")
+		err = spinnerHandler.WithSpinner(func() error {
+			response, err = chatCompletion(prompt)
+			return err
+		})
+	} else {
+		response, err = chatCompletion(prompt)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Drop code fences and any version heading the model added anyway
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(response), "\n") {
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "## ") || strings.HasPrefix(line, "# ") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	notes := strings.TrimSpace(strings.Join(lines, "\n"))
+	if notes == "" {
+		return "", fmt.Errorf("AI returned empty release notes")
+	}
+	return notes, nil
+}
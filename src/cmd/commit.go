@@ -2,26 +2,48 @@ package cmd
 
 import (
 	"bufio"
-	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/fatih/color"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"zero-workflow/src/internal/commitmsg"
 	zeroconfig "zero-workflow/src/internal/config"
+	"zero-workflow/src/internal/gitutil"
 	"zero-workflow/src/internal/handlers"
-	"zero-workflow/src/pkg/ai/zai"
+	"zero-workflow/src/internal/patch"
 	"zero-workflow/src/pkg/errors"
 )
 
 var (
-	commitLang string
-	autoPush   bool
+	commitLang     string
+	autoPush       bool
+	commitScope    string
+	commitHistory  int
+	commitTrailers []string
+	commitHookFile string
+	commitYes      bool
+	commitDryRun   bool
+	commitOutput   string
+	commitSign     bool
+	commitNoSign   bool
+	commitSplit    bool
+	commitAmend    bool
+
+	pushRemote         string
+	pushSetUpstream    bool
+	pushForceWithLease bool
 )
 
 var commitCmd = &cobra.Command{
@@ -31,16 +53,70 @@ var commitCmd = &cobra.Command{
 Follows Conventional Commits format.
 
 Supported languages: ru (Russian), en (English), uk (Ukrainian), kz (Kazakh)`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runCommit,
 }
 
 func init() {
 	rootCmd.AddCommand(commitCmd)
-	commitCmd.Flags().StringVarP(&commitLang, "lang", "l", "en", "Language for commit messages (ru, en, uk, kz)")
+	addMessageFlags(commitCmd)
 	commitCmd.Flags().BoolVarP(&autoPush, "push", "p", false, "Automatically push after commit (if remote exists)")
+	commitCmd.Flags().StringVar(&pushRemote, "remote", "", "Push to this remote instead of the branch's push remote or upstream (implies --push)")
+	commitCmd.Flags().BoolVarP(&pushSetUpstream, "set-upstream", "u", false, "Make the pushed branch the upstream of the current branch (implies --push)")
+	commitCmd.Flags().BoolVar(&pushForceWithLease, "force-with-lease", false, "Force the push unless the remote branch changed since last fetch, e.g. after --amend (implies --push)")
+	commitCmd.Flags().StringVarP(&commitOutput, "output", "o", "text", "Output format (text, json)")
+	commitCmd.Flags().StringVar(&commitHookFile, "hook", "", "Write the message into git's message file instead of committing (used by the prepare-commit-msg hook)")
+	commitCmd.Flags().BoolVar(&commitSplit, "split", false, "Group all working-tree changes into several commits proposed by AI")
+	commitCmd.Flags().BoolVar(&commitAmend, "amend", false, "Regenerate the message of HEAD from its changes plus anything newly staged, and replace HEAD")
+	commitCmd.Flags().StringArrayVar(&commitTrailers, "trailer", []string{}, "Add a trailer such as \"Co-authored-by: Name <email>\" (can be used multiple times)")
+}
+
+// addMessageFlags registers the flags shared by commit and reword, which
+// generate a message and write commits the same way
+func addMessageFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&commitLang, "lang", "l", "en", "Language for commit messages (ru, en, uk, kz)")
+	cmd.Flags().StringVarP(&commitScope, "scope", "s", "", "Force the commit scope instead of inferring it from changed paths")
+	cmd.Flags().IntVar(&commitHistory, "history", 0, "Learn commit style from the last N commits (0 disables, default from .zw/config)")
+	cmd.Flags().BoolVarP(&commitYes, "yes", "y", false, "Write commits without asking for confirmation")
+	cmd.Flags().BoolVar(&commitDryRun, "dry-run", false, "Print the generated message without writing any commit")
+	cmd.Flags().BoolVarP(&commitSign, "gpg-sign", "S", false, "Sign the written commits even if commit.gpgsign is not set")
+	cmd.Flags().BoolVar(&commitNoSign, "no-gpg-sign", false, "Do not sign the written commits even if commit.gpgsign is set")
 }
 
 func runCommit(cmd *cobra.Command, args []string) error {
+	if commitHookFile != "" {
+		return runCommitHook(cmd, args)
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected argument %q (the source argument is only valid with --hook)", args[0])
+	}
+	if commitOutput != "text" && commitOutput != "json" {
+		return fmt.Errorf("unsupported output format: %s. Supported: text, json", commitOutput)
+	}
+
+	jsonOutput := commitOutput == "json"
+	interactive := !commitYes && !commitDryRun && !jsonOutput && term.IsTerminal(int(os.Stdin.Fd()))
+	dryRun := commitDryRun || (!commitYes && !interactive)
+	if !commitYes && !commitDryRun && !jsonOutput && !interactive {
+		fmt.Fprintln(os.Stderr, "stdin is not a terminal; showing the message only (use --yes to commit)")
+	}
+
+	// Human-readable progress goes to stderr whenever stdout carries the result
+	out := io.Writer(os.Stdout)
+	if jsonOutput || dryRun {
+		out = os.Stderr
+	}
+
+	if commitSplit && commitAmend {
+		return fmt.Errorf("--split and --amend cannot be used together")
+	}
+	if pushRemote != "" || pushSetUpstream || pushForceWithLease {
+		autoPush = true
+	}
+	if commitSplit {
+		return runCommitSplit(cmd, interactive, dryRun, jsonOutput, out)
+	}
+
 	// Open git repository
 	repo, err := git.PlainOpen(".")
 	if err != nil {
@@ -53,57 +129,97 @@ func runCommit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	// Get status
-	status, err := worktree.Status()
-	if err != nil {
-		return fmt.Errorf("failed to get git status: %w", err)
+	// With --amend the message describes HEAD's own changes plus the staged ones
+	var amended *object.Commit
+	var base string
+	if commitAmend {
+		if amended, base, err = amendTarget(repo, out); err != nil {
+			return err
+		}
 	}
 
-	// Check if there are staged changes
-	hasStagedChanges := false
 	var stagedFiles []string
-	
-	for file, fileStatus := range status {
-		if fileStatus.Staging != git.Unmodified {
-			hasStagedChanges = true
-			stagedFiles = append(stagedFiles, file)
+	if amended != nil {
+		if stagedFiles, err = getStagedFiles(base); err != nil {
+			return err
 		}
+	} else {
+		// Get status
+		status, err := worktree.Status()
+		if err != nil {
+			return fmt.Errorf("failed to get git status: %w", err)
+		}
+
+		// Check if there are staged changes
+		for file, fileStatus := range status {
+			if fileStatus.Staging != git.Unmodified && fileStatus.Staging != git.Untracked {
+				stagedFiles = append(stagedFiles, file)
+			}
+		}
+		sort.Strings(stagedFiles)
 	}
 
-	if !hasStagedChanges {
-		color.Yellow("No staged changes found. Use 'git add' to stage files first.")
+	report := &commitReport{Files: stagedFiles, Candidates: []commitCandidate{}}
+	if len(stagedFiles) == 0 {
+		if jsonOutput {
+			report.Files = []string{}
+			return writeJSON(report)
+		}
+		fmt.Fprintln(out, color.YellowString("No staged changes found. Use 'git add' to stage files first."))
 		return nil
 	}
 
 	// Show staged files
-	color.Cyan("Staged files:")
+	if amended != nil {
+		fmt.Fprintln(out, color.CyanString("Current message:"))
+		fmt.Fprintf(out, "  %s\n\n", color.HiBlackString(strings.SplitN(strings.TrimSpace(amended.Message), "\n", 2)[0]))
+		fmt.Fprintln(out, color.CyanString("Files in amended commit:"))
+	} else {
+		fmt.Fprintln(out, color.CyanString("Staged files:"))
+	}
 	for _, file := range stagedFiles {
-		fmt.Printf("  %s %s\n", color.GreenString("✓"), file)
+		fmt.Fprintf(out, "  %s %s\n", color.GreenString("✓"), file)
 	}
-	fmt.Println()
+	fmt.Fprintln(out)
 
 	// Get diff for staged changes
-	diff, err := getStagedDiff(repo)
+	diff, err := getStagedDiff(repo, base)
 	if err != nil {
 		return fmt.Errorf("failed to get diff: %w", err)
 	}
 
-	var selectedCommit CommitOption
-	for {
-		// Generate commit message using AI with spinner
-		spinnerHandler := handlers.NewSpinnerHandler("Analyzing changes with AI")
-		var commitOptions []CommitOption
-		var err error
+	if jsonOutput {
+		if report.Stats, err = getStagedStats(base); err != nil {
+			return fmt.Errorf("failed to get diff statistics: %w", err)
+		}
+	}
 
-		err = spinnerHandler.WithSpinner(func() error {
-			commitOptions, err = generateCommitMessages(diff, stagedFiles)
-			return err
-		})
+	setup, err := prepareCommit(cmd, repo, worktree.Filesystem.Root(), stagedFiles, diff)
+	if err != nil {
+		return err
+	}
+	if amended != nil {
+		// Keep trailers such as Signed-off-by or Co-authored-by from the old message
+		setup.assemble.Trailers = append(commitmsg.MessageTrailers(amended.Message), setup.assemble.Trailers...)
+	}
 
+	// Resolve signing before generating so misconfiguration fails fast
+	signer, err := commitSigner(setup)
+	if err != nil {
+		return err
+	}
+
+	var commitMessage string
+	for {
+		// Generate commit message using AI
+		commitOptions, err := generateWithProgress(setup.request, interactive)
 		if err != nil {
 			return fmt.Errorf("failed to generate commit messages: %w", err)
 		}
 		if len(commitOptions) == 0 {
+			if !interactive {
+				return fmt.Errorf("no commit message generated")
+			}
 			color.Yellow("AI failed to generate a commit message.")
 			fmt.Print("Retry? (y/N): ")
 			reader := bufio.NewReader(os.Stdin)
@@ -113,14 +229,30 @@ func runCommit(cmd *cobra.Command, args []string) error {
 			}
 			return fmt.Errorf("no commit message generated")
 		}
-		selectedCommit = commitOptions[0]
+
+		report.Candidates = report.Candidates[:0]
+		for _, option := range commitOptions {
+			report.Candidates = append(report.Candidates, commitCandidate{
+				Title:       option.Title,
+				Description: option.Description,
+				Message:     commitmsg.Assemble(option.Title, option.Description, setup.assemble),
+			})
+		}
+		commitMessage = report.Candidates[0].Message
 
 		// Display generated commit
-		fmt.Println()
-		color.Cyan("Generated commit message:")
-		fmt.Printf("%s %s\n", color.GreenString("→"), color.WhiteString(selectedCommit.Title))
-		if selectedCommit.Description != "" {
-			fmt.Printf("  %s\n", color.HiBlackString(selectedCommit.Description))
+		title, description, _ := strings.Cut(commitMessage, "\n\n")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, color.CyanString("Generated commit message:"))
+		fmt.Fprintf(out, "%s %s\n", color.GreenString("→"), color.WhiteString(title))
+		if description != "" {
+			for _, line := range strings.Split(description, "\n") {
+				fmt.Fprintf(out, "  %s\n", color.HiBlackString(line))
+			}
+		}
+
+		if !interactive {
+			break
 		}
 
 		// Ask for user confirmation
@@ -141,95 +273,231 @@ func runCommit(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if dryRun {
+		if jsonOutput {
+			return writeJSON(report)
+		}
+		fmt.Println(commitMessage)
+		return nil
+	}
 
 	// Create commit
-	commitMessage := selectedCommit.Title
-	if selectedCommit.Description != "" {
-		commitMessage += "\n\n" + selectedCommit.Description
+	commitOptions := &git.CommitOptions{
+		Author:    &setup.identity.Author,
+		Committer: &setup.identity.Committer,
 	}
-
-	userName, err := getGitConfig("user.name")
-	if err != nil {
-		return err
+	if amended != nil {
+		// Like "git commit --amend", keep the original authorship
+		commitOptions.Amend = true
+		commitOptions.Author = &amended.Author
 	}
-	userEmail, err := getGitConfig("user.email")
-	if err != nil {
-		return err
+	if signer != nil {
+		commitOptions.Signer = signer
 	}
 
-	commit, err := worktree.Commit(commitMessage, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  userName,
-			Email: userEmail,
-			When:  time.Now(),
-		},
-	})
+	commit, err := worktree.Commit(commitMessage, commitOptions)
 	if err != nil {
 		return fmt.Errorf("failed to create commit: %w", err)
 	}
 
-	color.Green("✓ Commit created successfully: %s", commit.String()[:8])
-	
+	report.Committed = true
+	report.Commit = commit.String()
+	fmt.Fprintln(out, color.GreenString("✓ Commit created successfully: %s", commit.String()[:8]))
+
 	// Auto push if requested and remote exists
 	if autoPush {
-		if err := pushToRemote(repo); err != nil {
-			color.Yellow("Warning: Failed to push: %v", err)
+		if destination, err := pushToRemote(repo); err != nil {
+			fmt.Fprintln(out, color.YellowString("Warning: Failed to push: %v", err))
+			if commitAmend && !pushForceWithLease {
+				fmt.Fprintln(out, "If the original commit was already pushed, push again with --force-with-lease")
+			}
 		} else {
-			color.Green("✓ Pushed to remote successfully")
+			report.Pushed = true
+			fmt.Fprintln(out, color.GreenString("✓ Pushed to %s", destination))
 		}
 	}
-	
+
+	if jsonOutput {
+		return writeJSON(report)
+	}
 	return nil
 }
 
-type CommitOption struct {
-	Title       string
-	Description string
-}
+// amendTarget returns HEAD and the revision its changes are diffed against.
+// Amending a commit that is already on a remote only warns, as git does.
+func amendTarget(repo *git.Repository, out io.Writer) (*object.Commit, string, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, "", fmt.Errorf("nothing to amend: %w", err)
+	}
 
-func generateCommitMessages(diff string, files []string) ([]CommitOption, error) {
-	token, err := zeroconfig.GetToken()
+	commit, err := repo.CommitObject(head.Hash())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get AI token: %w", err)
+		return nil, "", fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+	if commit.NumParents() > 1 {
+		return nil, "", fmt.Errorf("HEAD is a merge commit; amending merges is not supported")
 	}
 
-	client, err := zai.NewClient(token)
+	published, err := gitutil.PublishedIn(repo, commit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create AI client: %w", err)
+		return nil, "", fmt.Errorf("failed to check remote branches: %w", err)
+	}
+	if published != "" {
+		fmt.Fprintln(out, color.YellowString("Warning: HEAD is already in %s; the amended commit will need a push with --force-with-lease.", published))
 	}
 
-	// Validate language
-	if !isValidLanguage(commitLang) {
-		return nil, fmt.Errorf("unsupported language: %s. Supported: ru, en, uk, kz", commitLang)
+	base := gitutil.EmptyTree
+	if commit.NumParents() == 1 {
+		base = commit.ParentHashes[0].String()
 	}
+	return commit, base, nil
+}
 
-	langInstructions := getLanguageInstructions(commitLang)
-	
-	prompt := fmt.Sprintf(`%s
+// commitReport is the machine-readable result of "zw commit --output json"
+type commitReport struct {
+	Files      []string          `json:"files"`
+	Stats      diffStats         `json:"stats"`
+	Candidates []commitCandidate `json:"candidates"`
+	Committed  bool              `json:"committed"`
+	Commit     string            `json:"commit,omitempty"`
+	Pushed     bool              `json:"pushed,omitempty"`
+}
+
+// commitCandidate is a generated message; Message includes issue keys and trailers
+type commitCandidate struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Message     string `json:"message"`
+}
+
+// diffStats summarises "git diff --numstat"
+type diffStats struct {
+	Files      int        `json:"files"`
+	Insertions int        `json:"insertions"`
+	Deletions  int        `json:"deletions"`
+	PerFile    []fileStat `json:"per_file"`
+}
+
+// fileStat holds line counts for one file; binary files have none
+type fileStat struct {
+	Path       string `json:"path"`
+	Insertions int    `json:"insertions"`
+	Deletions  int    `json:"deletions"`
+	Binary     bool   `json:"binary,omitempty"`
+}
+
+// writeJSON prints the report of a command run with --output json
+func writeJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// commitSetup bundles what both the interactive flow and the git hook need
+type commitSetup struct {
+	request    commitRequest
+	assemble   commitmsg.AssembleOptions
+	repoConfig *zeroconfig.RepoConfig
+	gitConfig  *gitutil.Config
+	identity   *gitutil.Identity
+}
+
+// prepareCommit infers the scope, learns the house style and resolves issue keys
+// and trailers for the staged files of the repository rooted at root
+func prepareCommit(cmd *cobra.Command, repo *git.Repository, root string, stagedFiles []string, diff string) (*commitSetup, error) {
+	// Infer scope from repository layout
+	repoConfig, err := zeroconfig.LoadRepoConfig(root)
+	if err != nil {
+		return nil, err
+	}
 
-Analyze the following git diff and generate 1 professional commit message following Conventional Commits format.
+	scope := commitmsg.InferScope(root, stagedFiles, repoConfig.Scope)
+	if commitScope != "" {
+		scope = commitmsg.Scope{Name: commitScope, Candidates: []string{commitScope}}
+	}
 
-Files changed: %s
+	setup := &commitSetup{
+		request: commitRequest{
+			Diff:  diff,
+			Files: stagedFiles,
+			Scope: scope,
+			Lang:  commitLang,
+		},
+		repoConfig: repoConfig,
+	}
 
-Diff:
-%s
+	// Learn house style from recent history
+	history := repoConfig.Style.History
+	if cmd.Flags().Changed("history") {
+		history = commitHistory
+	}
+	if history > 0 {
+		messages, err := recentCommitMessages(repo, history)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit history: %w", err)
+		}
+		setup.request.Style = commitmsg.AnalyzeStyle(messages, repoConfig.Style.Examples)
+		if setup.request.Style.Language != "" && !cmd.Flags().Changed("lang") {
+			setup.request.Lang = setup.request.Style.Language
+		}
+	}
 
-Requirements:
-1. Use conventional commit format: type(scope): description
-2. Types: feat, fix, docs, style, refactor, test, chore
-3. Keep title under 50 characters
-4. Provide optional detailed description for complex changes
-5. Be specific and descriptive
-6. Choose the most appropriate commit type and description
+	// Resolve identity the way git does (config files, includes and environment)
+	setup.gitConfig, err = gitutil.LoadConfig(repo)
+	if err != nil {
+		return nil, err
+	}
+	setup.identity, err = setup.gitConfig.Identity()
+	if err != nil {
+		return nil, err
+	}
 
-Return in this exact format:
-type(scope): short description
-Optional longer description explaining the change`, langInstructions, strings.Join(files, ", "), diff)
+	// Issue keys and trailers are added around whatever the model generates
+	setup.assemble, err = buildAssembleOptions(repo, repoConfig, setup.identity.Committer)
+	if err != nil {
+		return nil, err
+	}
 
-	ctx := context.Background()
-	response, err := client.Chat(ctx, prompt)
+	return setup, nil
+}
+
+type CommitOption struct {
+	Title       string
+	Description string
+}
+
+// commitRequest holds everything the model needs to describe a change
+type commitRequest struct {
+	Diff  string
+	Files []string
+	Scope commitmsg.Scope
+	Lang  string
+	Style *commitmsg.Style
+}
+
+func generateCommitMessages(request commitRequest) ([]CommitOption, error) {
+	prompt, err := commitPrompt(request)
 	if err != nil {
-		return nil, fmt.Errorf("AI generation failed: %w", err)
+		return nil, err
+	}
+	return completeCommitMessages(request, prompt)
+}
+
+// commitPrompt builds the prompt for request and redacts it, printing any
+// warning before a spinner starts
+func commitPrompt(request commitRequest) (string, error) {
+	if !isValidLanguage(request.Lang) {
+		return "", fmt.Errorf("unsupported language: %s. Supported: ru, en, uk, kz", request.Lang)
+	}
+	return redactPrompt(buildCommitPrompt(request))
+}
+
+// completeCommitMessages sends a prompt from commitPrompt and parses the options
+func completeCommitMessages(request commitRequest, prompt string) ([]CommitOption, error) {
+	response, err := chatCompletion(prompt)
+	if err != nil {
+		return nil, err
 	}
 
 	options := parseCommitOptions(response)
@@ -237,9 +505,151 @@ Optional longer description explaining the change`, langInstructions, strings.Jo
 		return nil, fmt.Errorf("failed to parse AI response")
 	}
 
+	// Enforce the inferred scope even if the model ignored the constraint
+	for i := range options {
+		options[i].Title = request.Scope.Apply(options[i].Title)
+	}
+
 	return options, nil
 }
 
+// generateWithProgress generates messages, showing a spinner when interactive.
+// The spinner draws on stdout, so it is never used when stdout carries the result.
+func generateWithProgress(request commitRequest, interactive bool) ([]CommitOption, error) {
+	if !interactive {
+		return generateCommitMessages(request)
+	}
+
+	prompt, err := commitPrompt(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var options []CommitOption
+	spinnerHandler := handlers.NewSpinnerHandler("Analyzing changes with AI")
+	err = spinnerHandler.WithSpinner(func() error {
+		var err error
+		options, err = completeCommitMessages(request, prompt)
+		return err
+	})
+	return options, err
+}
+
+// buildAssembleOptions collects issue keys from the branch name and configured trailers.
+// Trailer values may reference {name} and {email} of the committer.
+func buildAssembleOptions(repo *git.Repository, repoConfig *zeroconfig.RepoConfig, committer object.Signature) (commitmsg.AssembleOptions, error) {
+	opts := commitmsg.AssembleOptions{
+		IssuePlacement: repoConfig.Issue.Placement,
+		IssueTrailer:   repoConfig.Issue.Trailer,
+	}
+
+	if head, err := repo.Head(); err == nil && head.Name().IsBranch() {
+		opts.Issues = commitmsg.ExtractIssues(head.Name().Short(), repoConfig.Issue.Pattern)
+	}
+
+	placeholders := strings.NewReplacer("{name}", committer.Name, "{email}", committer.Email)
+	for _, raw := range append(append([]string{}, repoConfig.Trailers...), commitTrailers...) {
+		trailer, err := commitmsg.ParseTrailer(placeholders.Replace(raw))
+		if err != nil {
+			return opts, err
+		}
+		opts.Trailers = append(opts.Trailers, trailer)
+	}
+
+	return opts, nil
+}
+
+// buildCommitPrompt assembles the generation prompt. Learned repository
+// conventions replace the generic formatting requirements when available.
+func buildCommitPrompt(request commitRequest) string {
+	conventional := request.Style == nil || request.Style.Samples == 0 || request.Style.Conventional >= 0.5
+
+	var requirements []string
+	if request.Style == nil || request.Style.Samples == 0 {
+		requirements = []string{
+			"Use conventional commit format: type(scope): description",
+			"Types: feat, fix, docs, style, refactor, test, chore",
+			"Keep title under 50 characters",
+			"Provide optional detailed description for complex changes",
+		}
+	} else if conventional {
+		requirements = []string{"Use conventional commit format: type(scope): description"}
+	}
+	requirements = append(requirements,
+		"Be specific and descriptive",
+		"Choose the most appropriate commit type and description",
+	)
+	if constraint := request.Scope.Constraint(); constraint != "" {
+		requirements = append(requirements, constraint)
+	}
+
+	var builder strings.Builder
+	builder.WriteString(getLanguageInstructions(request.Lang))
+	builder.WriteString("\n\nAnalyze the following git diff and generate 1 professional commit message")
+	if conventional {
+		builder.WriteString(" following Conventional Commits format")
+	}
+	builder.WriteString(".\n\n")
+	builder.WriteString(fmt.Sprintf("Files changed: %s\n\n", strings.Join(request.Files, ", ")))
+	builder.WriteString(fmt.Sprintf("Diff:\n%s\n\n", request.Diff))
+
+	builder.WriteString("Requirements:\n")
+	for i, requirement := range requirements {
+		builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, requirement))
+	}
+
+	if description := request.Style.Describe(); description != "" {
+		builder.WriteString("\n" + description)
+	}
+	if examples := request.Style.FewShot(); examples != "" {
+		builder.WriteString("\n" + examples)
+	}
+
+	builder.WriteString("\nReturn in this exact format:\n")
+	if conventional {
+		builder.WriteString("type(scope): short description\n")
+	} else {
+		builder.WriteString("Short title\n")
+	}
+	builder.WriteString("Optional longer description explaining the change")
+
+	return builder.String()
+}
+
+// recentCommitMessages returns up to n non-merge commit messages from HEAD, newest first
+func recentCommitMessages(repo *git.Repository, n int) ([]string, error) {
+	head, err := repo.Head()
+	if err != nil {
+		if err == plumbing.ErrReferenceNotFound {
+			return nil, nil // no commits yet
+		}
+		return nil, err
+	}
+
+	iter, err := repo.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var messages []string
+	err = iter.ForEach(func(c *object.Commit) error {
+		if len(messages) >= n {
+			return storer.ErrStop
+		}
+		if c.NumParents() > 1 {
+			return nil
+		}
+		messages = append(messages, c.Message)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
 func isValidLanguage(lang string) bool {
 	validLangs := []string{"ru", "en", "uk", "kz"}
 	for _, valid := range validLangs {
@@ -300,15 +710,88 @@ func parseCommitOptions(response string) []CommitOption {
 	return options
 }
 
-func getStagedDiff(repo *git.Repository) (string, error) {
+// stagedArgs builds "git diff --staged" arguments. A non-empty base compares the
+// index with that commit instead of HEAD, which is how --amend sees HEAD's changes.
+func stagedArgs(base string, flags ...string) []string {
+	args := append([]string{"--staged"}, flags...)
+	if base != "" {
+		args = append(args, base)
+	}
+	return args
+}
+
+// getStagedFiles lists staged paths using git itself, which honours GIT_INDEX_FILE
+// when running inside hooks (e.g. for "git commit -a")
+func getStagedFiles(base string) ([]string, error) {
+	args := stagedArgs(base, "--name-only")
+	if err := errors.ValidateGitCommand("diff", args); err != nil {
+		return nil, fmt.Errorf("git command validation failed: %w", err)
+	}
+
+	output, err := exec.Command("git", append([]string{"diff"}, args...)...).Output()
+	if err != nil {
+		return nil, errors.NewGitError("diff", args, "failed to list staged files", err)
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// getStagedStats returns per-file line counts of the staged changes
+func getStagedStats(base string) (diffStats, error) {
+	stats := diffStats{PerFile: []fileStat{}}
+
+	args := stagedArgs(base, "--numstat")
+	if err := errors.ValidateGitCommand("diff", args); err != nil {
+		return stats, fmt.Errorf("git command validation failed: %w", err)
+	}
+
+	output, err := exec.Command("git", append([]string{"diff"}, args...)...).Output()
+	if err != nil {
+		return stats, errors.NewGitError("diff", args, "failed to get diff statistics", err)
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		stat := fileStat{Path: fields[2]}
+		if fields[0] == "-" && fields[1] == "-" {
+			stat.Binary = true
+		} else {
+			stat.Insertions, _ = strconv.Atoi(fields[0])
+			stat.Deletions, _ = strconv.Atoi(fields[1])
+		}
+
+		stats.Files++
+		stats.Insertions += stat.Insertions
+		stats.Deletions += stat.Deletions
+		stats.PerFile = append(stats.PerFile, stat)
+	}
+
+	return stats, nil
+}
+
+// diffLimit bounds the diff every command sends to the model; larger diffs are
+// budgeted per file and cut at hunk boundaries, see patch.Budget
+const diffLimit = 16000
+
+func getStagedDiff(repo *git.Repository, base string) (string, error) {
 	// Validate git command for security
-	args := []string{"--staged"}
+	args := stagedArgs(base)
 	if err := errors.ValidateGitCommand("diff", args); err != nil {
 		return "", fmt.Errorf("git command validation failed: %w", err)
 	}
 
 	// Use the system's `git diff` command for a reliable and standard diff.
-	cmd := exec.Command("git", "diff", "--staged")
+	cmd := exec.Command("git", append([]string{"diff"}, args...)...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		if len(output) == 0 {
@@ -316,67 +799,85 @@ func getStagedDiff(repo *git.Repository) (string, error) {
 		}
 	}
 
-	result := string(output)
-
-	// Limit diff size to avoid overwhelming the AI model and hitting token limits.
-	if len(result) > 8000 {
-		result = result[:8000] + "\n... (diff truncated)"
-	}
-
-	return result, nil
+	return patch.Budget(patch.Parse(string(output)), diffLimit), nil
 }
 
 
 
-func pushToRemote(repo *git.Repository) error {
+// pushToRemote pushes the current branch to its push remote, honouring --remote,
+// --set-upstream and --force-with-lease. A branch that tracks a branch of that
+// remote is pushed to it, like "git push" with push.default=upstream, even when
+// the names differ; otherwise it is pushed to a branch of the same name, which
+// becomes its upstream if the branch tracks nothing yet. It returns the "remote/branch" pushed to.
+func pushToRemote(repo *git.Repository) (string, error) {
 	// Get current branch
 	head, err := repo.Head()
 	if err != nil {
-		return fmt.Errorf("failed to get HEAD: %w", err)
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
 	}
-	
-	// Check if remote exists
-	_, err = repo.Remote("origin")
+	if !head.Name().IsBranch() {
+		return "", fmt.Errorf("HEAD is detached; check out a branch to push")
+	}
+	branch := head.Name().Short()
+
+	gitConfig, err := gitutil.LoadConfig(repo)
 	if err != nil {
-		return fmt.Errorf("no remote 'origin' found: %w", err)
+		return "", fmt.Errorf("failed to read git config: %w", err)
 	}
-	
+	target := gitConfig.PushTarget(branch, pushRemote)
+
+	// Check if remote exists
+	if _, err := repo.Remote(target.Remote); err != nil {
+		return "", fmt.Errorf("no remote '%s' found: %w", target.Remote, err)
+	}
+
+	var args []string
+	if pushForceWithLease {
+		args = append(args, "--force-with-lease")
+	}
+	// A branch that tracks something keeps its upstream, e.g. when pushing to a
+	// fork with branch.<name>.pushRemote, unless -u asks to change it
+	if pushSetUpstream || !target.Tracking {
+		args = append(args, "--set-upstream")
+	}
+	refspec, destination := branch, branch
+	if target.Upstream != "" {
+		refspec, destination = branch+":refs/heads/"+target.Upstream, target.Upstream
+	}
+	args = append(args, target.Remote, refspec)
+
 	// Validate git command for security
-	args := []string{"origin", head.Name().Short()}
 	if err := errors.ValidateGitCommand("push", args); err != nil {
-		return fmt.Errorf("git command validation failed: %w", err)
+		return "", fmt.Errorf("git command validation failed: %w", err)
 	}
-	
+
 	// Use system git command for push to leverage existing auth
 	// This avoids authentication issues with go-git
-	cmd := exec.Command("git", "push", "origin", head.Name().Short())
+	cmd := exec.Command("git", append([]string{"push"}, args...)...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		// Sanitize output to prevent token leakage
 		sanitizedOutput := errors.SanitizeForLog(fmt.Errorf(string(output)))
-		return errors.NewGitError("push", args, "failed to push", fmt.Errorf(sanitizedOutput))
+		return "", errors.NewGitError("push", args, "failed to push", fmt.Errorf(sanitizedOutput))
 	}
-	
-	return nil
+
+	return target.Remote + "/" + destination, nil
 }
 
-func getGitConfig(key string) (string, error) {
-	// Validate git command for security
-	args := []string{key}
-	if err := errors.ValidateGitCommand("config", args); err != nil {
-		return "", fmt.Errorf("git command validation failed: %w", err)
+// commitSigner returns a signer when commit.gpgsign (or --gpg-sign) asks for one
+func commitSigner(setup *commitSetup) (git.Signer, error) {
+	if commitSign && commitNoSign {
+		return nil, fmt.Errorf("--gpg-sign and --no-gpg-sign cannot be used together")
 	}
 
-	cmd := exec.Command("git", "config", key)
-	output, err := cmd.Output()
-	if err != nil {
-		return "", errors.NewGitError("config", args, fmt.Sprintf("git config %s is not set. Please configure it using git config --global %s \"Your Name/Email\"", key, key), err)
+	cfg := setup.gitConfig.Signing()
+	if commitNoSign || (!cfg.Enabled && !commitSign) {
+		return nil, nil
 	}
-	
-	value := strings.TrimSpace(string(output))
-	if value == "" {
-		return "", errors.NewGitError("config", args, fmt.Sprintf("git config %s is empty. Please configure it", key), nil)
+
+	signer, err := gitutil.NewSigner(cfg, setup.identity.CommitterIdent())
+	if err != nil {
+		return nil, err
 	}
-	
-	return value, nil
+	return signer, nil
 }
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"zero-workflow/src/internal/gitutil"
+	"zero-workflow/src/internal/handlers"
+	"zero-workflow/src/internal/patch"
+	"zero-workflow/src/internal/renderer"
+)
+
+const (
+	// explainCommitLimit is the most commits from a blame range that are explained
+	explainCommitLimit = 10
+	// explainFileDiffLimit bounds each commit's diff of the blamed file
+	explainFileDiffLimit = 3000
+)
+
+// lineRange matches the ":120-180" or ":120" suffix of a blame selector
+var lineRange = regexp.MustCompile(`^(.+):(\d+)(?:-(\d+))?$`)
+
+var explainLang string
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <rev | file:start-end>",
+	Short: "Explain a commit or the history of a range of lines",
+	Long: `Explains what a commit changed and why, or how a range of lines came to be.
+
+For a revision, the commit message and diff are sent to the AI. For a file with
+a line range, the lines are blamed at HEAD and the commits that last touched them
+are explained together with their changes to the file.
+
+Examples:
+  zw explain HEAD~2
+  zw explain 3f2c1ab
+  zw explain src/cmd/commit.go:120-180
+  zw explain main.go:42`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExplain,
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+	explainCmd.Flags().StringVarP(&explainLang, "lang", "l", "en", "Language of the explanation (ru, en, uk, kz)")
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	if !isValidLanguage(explainLang) {
+		return fmt.Errorf("unsupported language: %s. Supported: ru, en, uk, kz", explainLang)
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return fmt.Errorf("not a git repository: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	var prompt, title string
+	if path, start, end, ok := parseLineSelector(args[0]); ok {
+		rel, err := repoRelativePath(worktree.Filesystem.Root(), path)
+		if err != nil {
+			return err
+		}
+		title = fmt.Sprintf("%s:%d-%d", rel, start, end)
+		if prompt, err = buildBlamePrompt(repo, rel, start, end); err != nil {
+			return err
+		}
+	} else {
+		commit, err := resolveCommit(repo, args[0])
+		if err != nil {
+			return err
+		}
+		title = commit.Hash.String()[:7]
+		if prompt, err = buildCommitExplainPrompt(commit); err != nil {
+			return err
+		}
+	}
+
+	// Redact before the spinner starts so the warning is not overdrawn
+	if prompt, err = redactPrompt(prompt); err != nil {
+		return err
+	}
+
+	var response string
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		spinnerHandler := handlers.NewSpinnerHandler("Explaining " + title)
+		err = spinnerHandler.WithSpinner(func() error {
+			response, err = chatCompletion(prompt)
+			return err
+		})
+	} else {
+		response, err = chatCompletion(prompt)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(renderer.NewMarkdownRenderer().RenderMarkdown(strings.TrimSpace(response)))
+	return nil
+}
+
+// parseLineSelector splits "file:120-180" or "file:42" into its parts; ok is false
+// for anything else, which is then treated as a revision
+func parseLineSelector(arg string) (string, int, int, bool) {
+	parts := lineRange.FindStringSubmatch(arg)
+	if parts == nil {
+		return "", 0, 0, false
+	}
+	if _, err := os.Stat(parts[1]); err != nil {
+		return "", 0, 0, false
+	}
+
+	start, err := strconv.Atoi(parts[2])
+	if err != nil || start < 1 {
+		return "", 0, 0, false
+	}
+	end := start
+	if parts[3] != "" {
+		if end, err = strconv.Atoi(parts[3]); err != nil || end < start {
+			return "", 0, 0, false
+		}
+	}
+	return parts[1], start, end, true
+}
+
+// repoRelativePath converts a path given on the command line to a slash-separated
+// path relative to the repository root, as used in git trees
+func repoRelativePath(root, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	if resolvedRoot, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolvedRoot
+	}
+
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the repository", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// buildCommitExplainPrompt describes a commit with its message and budgeted diff
+func buildCommitExplainPrompt(commit *object.Commit) (string, error) {
+	from := gitutil.EmptyTree
+	if commit.NumParents() > 0 {
+		from = commit.ParentHashes[0].String()
+	}
+	diff, err := getRangeDiff(from, commit.Hash.String())
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Answer in %s.\n\n", languageName(explainLang)))
+	builder.WriteString("Explain the following git commit to a developer who is new to this code.\n\n")
+	builder.WriteString(describeCommit(commit))
+	if commit.NumParents() > 1 {
+		builder.WriteString("This is a merge commit; the diff is against its first parent.\n")
+	}
+	builder.WriteString("\nDiff:\n```diff\n" + patch.Budget(patch.Parse(diff), diffLimit) + "\n```\n\n")
+
+	builder.WriteString(`Structure the answer in Markdown:
+1. Summary: what the commit does, in two or three sentences
+2. Changes: the important changes, file by file where it helps
+3. Why: the motivation, based on the message and the code; say so when it is a guess
+4. Things to watch: risks, behaviour changes or follow-ups, if any`)
+
+	return builder.String(), nil
+}
+
+// buildBlamePrompt blames lines start..end of path at HEAD and describes the
+// commits that last touched them, newest first
+func buildBlamePrompt(repo *git.Repository, path string, start, end int) (string, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return "", fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+
+	blame, err := git.Blame(headCommit, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to blame %s at HEAD: %w", path, err)
+	}
+	if start > len(blame.Lines) {
+		return "", fmt.Errorf("%s has only %d lines at HEAD", path, len(blame.Lines))
+	}
+	if end > len(blame.Lines) {
+		end = len(blame.Lines)
+	}
+
+	var code strings.Builder
+	touched := map[plumbing.Hash]int{}
+	for i := start; i <= end; i++ {
+		line := blame.Lines[i-1]
+		code.WriteString(fmt.Sprintf("%5d  %s  %s\n", i, line.Hash.String()[:7], line.Text))
+		touched[line.Hash]++
+	}
+
+	var commits []*object.Commit
+	for hash := range touched {
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return "", fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		commits = append(commits, commit)
+	}
+	sort.Slice(commits, func(a, b int) bool {
+		return commits[a].Committer.When.After(commits[b].Committer.When)
+	})
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Answer in %s.\n\n", languageName(explainLang)))
+	builder.WriteString(fmt.Sprintf("Explain how lines %d-%d of %s came to be and what they do, for a developer who is new to this code.\n\n", start, end, path))
+	builder.WriteString("Lines at HEAD (line number, commit that last changed the line, text):\n```\n" + code.String() + "```\n\n")
+
+	builder.WriteString(fmt.Sprintf("Commits that last changed these lines, newest first (%d in total):\n\n", len(commits)))
+	for i, commit := range commits {
+		if i == explainCommitLimit {
+			builder.WriteString(fmt.Sprintf("... %d older commit(s) omitted\n\n", len(commits)-explainCommitLimit))
+			break
+		}
+		builder.WriteString(describeCommit(commit))
+		builder.WriteString(fmt.Sprintf("Lines in range: %d\n", touched[commit.Hash]))
+
+		if fileDiff, err := commitFileDiff(commit, path); err == nil && fileDiff != "" {
+			builder.WriteString("Changes to " + path + ":\n```diff\n" + fileDiff + "\n```\n")
+		}
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString(`Structure the answer in Markdown:
+1. What the code does now
+2. History: how it evolved, commit by commit, and why each change was made, based on the messages and diffs; say so when it is a guess
+3. Things to watch: non-obvious constraints or workarounds the history reveals, if any`)
+
+	return builder.String(), nil
+}
+
+// commitFileDiff returns the commit's change to path, shortened to explainFileDiffLimit
+func commitFileDiff(commit *object.Commit, path string) (string, error) {
+	from := gitutil.EmptyTree
+	if commit.NumParents() > 0 {
+		from = commit.ParentHashes[0].String()
+	}
+	diff, err := getRangeDiff(from, commit.Hash.String())
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range patch.Parse(diff) {
+		if file.Path() == path {
+			return file.Truncate(explainFileDiffLimit), nil
+		}
+	}
+	return "", nil
+}
+
+func describeCommit(commit *object.Commit) string {
+	return fmt.Sprintf("Commit %s by %s on %s\nMessage:\n%s\n",
+		commit.Hash.String()[:7], commit.Author.Name, commit.Author.When.Format("2006-01-02"),
+		strings.TrimSpace(commit.Message))
+}
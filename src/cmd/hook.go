@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/spf13/cobra"
+	"zero-workflow/src/internal/commitmsg"
+	"zero-workflow/src/internal/gitutil"
+)
+
+const (
+	hookName      = "prepare-commit-msg"
+	hookMarker    = "# zw-prepare-commit-msg"
+	hookBackupExt = ".zw-backup"
+)
+
+// hookScript is installed as prepare-commit-msg. It never blocks a commit:
+// if zw is missing or fails, git continues with its usual message.
+const hookScript = `#!/bin/sh
+` + hookMarker + `
+# Installed by "zw hook install"; remove with "zw hook uninstall".
+ZW=%s
+[ -x "$ZW" ] || ZW=zw
+command -v "$ZW" >/dev/null 2>&1 || exit 0
+"$ZW" commit --hook "$1" "$2" </dev/null || true
+`
+
+var hookForce bool
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage the prepare-commit-msg git hook",
+	Long: `Installs a prepare-commit-msg hook so that plain "git commit" (or an IDE's commit
+button) opens the editor with an AI-generated draft. Messages given with -m, merges,
+squashes and amends are left untouched.`,
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the prepare-commit-msg hook",
+	Args:  cobra.NoArgs,
+	RunE:  runHookInstall,
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the prepare-commit-msg hook",
+	Args:  cobra.NoArgs,
+	RunE:  runHookUninstall,
+}
+
+func init() {
+	rootCmd.AddCommand(hookCmd)
+	hookCmd.AddCommand(hookInstallCmd)
+	hookCmd.AddCommand(hookUninstallCmd)
+	hookInstallCmd.Flags().BoolVar(&hookForce, "force", false, "Replace an existing hook (a backup is kept)")
+}
+
+func runHookInstall(cmd *cobra.Command, args []string) error {
+	path, err := hookPath()
+	if err != nil {
+		return err
+	}
+
+	if existing, err := os.ReadFile(path); err == nil && !strings.Contains(string(existing), hookMarker) {
+		if !hookForce {
+			return fmt.Errorf("%s already exists and was not installed by zw; use --force to replace it", path)
+		}
+		if err := os.Rename(path, path+hookBackupExt); err != nil {
+			return fmt.Errorf("failed to back up existing hook: %w", err)
+		}
+		color.Yellow("Existing hook saved to %s", path+hookBackupExt)
+	}
+
+	// Prefer the absolute path of this binary: IDEs often run hooks with a minimal PATH
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "zw"
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(hookScript, shellQuote(executable))), 0755); err != nil {
+		return fmt.Errorf("failed to write hook: %w", err)
+	}
+
+	color.Green("✓ Installed %s hook: %s", hookName, path)
+	return nil
+}
+
+func runHookUninstall(cmd *cobra.Command, args []string) error {
+	path, err := hookPath()
+	if err != nil {
+		return err
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			color.Yellow("No %s hook installed.", hookName)
+			return nil
+		}
+		return fmt.Errorf("failed to read hook: %w", err)
+	}
+	if !strings.Contains(string(existing), hookMarker) {
+		return fmt.Errorf("%s was not installed by zw; refusing to remove it", path)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove hook: %w", err)
+	}
+
+	if _, err := os.Stat(path + hookBackupExt); err == nil {
+		if err := os.Rename(path+hookBackupExt, path); err != nil {
+			return fmt.Errorf("failed to restore previous hook: %w", err)
+		}
+		color.Green("✓ Removed zw hook and restored the previous one")
+		return nil
+	}
+
+	color.Green("✓ Removed %s hook", hookName)
+	return nil
+}
+
+// shellQuote quotes s for sh: inside single quotes nothing is expanded, and a
+// single quote is written as '\''
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// hookPath resolves the prepare-commit-msg location, honouring core.hooksPath
+func hookPath() (string, error) {
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	cfg, err := gitutil.LoadConfig(repo)
+	if err != nil {
+		return "", err
+	}
+
+	if hooksPath := cfg.Get("core.hooksPath"); hooksPath != "" {
+		if strings.HasPrefix(hooksPath, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", fmt.Errorf("cannot expand core.hooksPath: %w", err)
+			}
+			hooksPath = filepath.Join(home, hooksPath[2:])
+		}
+		if !filepath.IsAbs(hooksPath) {
+			hooksPath = filepath.Join(worktree.Filesystem.Root(), hooksPath)
+		}
+		return filepath.Join(hooksPath, hookName), nil
+	}
+
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", fmt.Errorf("unsupported repository storage")
+	}
+	return filepath.Join(storage.Filesystem().Root(), "hooks", hookName), nil
+}
+
+// runCommitHook implements "zw commit --hook <msgfile> [source]". It writes a draft
+// into git's message file and never fails the commit: problems are reported as warnings.
+func runCommitHook(cmd *cobra.Command, args []string) error {
+	source := ""
+	if len(args) > 0 {
+		source = args[0]
+	}
+
+	// -m/-F, merges, squashes and amends already have a message
+	if source != "" && source != "template" {
+		return nil
+	}
+
+	if err := writeHookMessage(cmd, commitHookFile); err != nil {
+		fmt.Fprintf(os.Stderr, "zw: could not generate commit message: %v\n", err)
+	}
+	return nil
+}
+
+func writeHookMessage(cmd *cobra.Command, messageFile string) error {
+	existing, err := os.ReadFile(messageFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", messageFile, err)
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return fmt.Errorf("not a git repository: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	stagedFiles, err := getStagedFiles("")
+	if err != nil {
+		return err
+	}
+	if len(stagedFiles) == 0 {
+		return nil
+	}
+
+	diff, err := getStagedDiff(repo, "")
+	if err != nil {
+		return fmt.Errorf("failed to get diff: %w", err)
+	}
+
+	setup, err := prepareCommit(cmd, repo, worktree.Filesystem.Root(), stagedFiles, diff)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "zw: generating commit message...")
+	options, err := generateCommitMessages(setup.request)
+	if err != nil {
+		return err
+	}
+
+	message := commitmsg.Assemble(options[0].Title, options[0].Description, setup.assemble)
+
+	// Keep git's comments (and any template text) below the draft
+	content := message + "\n"
+	if len(existing) > 0 {
+		content += "\n" + string(existing)
+	}
+	if err := os.WriteFile(messageFile, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", messageFile, err)
+	}
+	return nil
+}
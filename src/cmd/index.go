@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"zero-workflow/src/internal/config"
+	"zero-workflow/src/internal/files"
+	"zero-workflow/src/internal/handlers"
+	"zero-workflow/src/internal/index"
+	"zero-workflow/src/internal/redact"
+)
+
+var (
+	indexRebuild      bool
+	indexNoEmbeddings bool
+)
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Build a local search index of the repository for zw ask --rag",
+	Long: `Splits the source files of the repository into chunks (Go files by function and
+type, other files by paragraph) and builds a BM25 keyword index under .zw/index.
+Running it again only re-reads files whose content changed.
+
+When an OpenAI-compatible embeddings endpoint is configured with ZW_EMBEDDINGS_URL,
+chunks also get embeddings, and zw ask --rag combines keyword and semantic search.
+
+Examples:
+  zw index
+  zw index --rebuild
+  ZW_EMBEDDINGS_URL=https://api.openai.com/v1 ZW_EMBEDDINGS_API_KEY=sk-... zw index`,
+	Args: cobra.NoArgs,
+	RunE: runIndex,
+}
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+	indexCmd.Flags().BoolVar(&indexRebuild, "rebuild", false, "Discard the existing index and build it from scratch")
+	indexCmd.Flags().BoolVar(&indexNoEmbeddings, "no-embeddings", false, "Build a keyword index only, even if embeddings are configured")
+}
+
+func runIndex(cmd *cobra.Command, args []string) error {
+	config.LoadEnv()
+
+	repo, root, err := openRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	ix := index.New(root)
+	if !indexRebuild {
+		if ix, err = index.Load(root); os.IsNotExist(err) {
+			ix = index.New(root)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	policy, err := files.LoadPolicy()
+	if err != nil {
+		return err
+	}
+	paths, err := index.SourceFiles(repo, policy)
+	if err != nil {
+		return err
+	}
+
+	var embedder *index.Embedder
+	var redactor *redact.Redactor
+	if !indexNoEmbeddings {
+		embedder = configuredEmbedder()
+	}
+	if embedder != nil {
+		if redactor, err = loadRedactor(); err != nil {
+			return err
+		}
+	}
+
+	var stats *index.UpdateStats
+	update := func() error {
+		stats, err = ix.Update(context.Background(), paths, embedder, redactor)
+		return err
+	}
+	if embedder != nil && term.IsTerminal(int(os.Stdout.Fd())) {
+		err = handlers.NewSpinnerHandler("Indexing").WithSpinner(update)
+	} else {
+		err = update()
+	}
+	if err != nil {
+		return err
+	}
+	reportRedactions(stats.Redacted)
+
+	if err := ix.Save(); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+
+	fmt.Printf("[*] Indexed %d file(s): %d added, %d updated, %d removed, %d unchanged\n",
+		stats.Added+stats.Updated+stats.Unchanged, stats.Added, stats.Updated, stats.Removed, stats.Unchanged)
+	if embedder != nil {
+		fmt.Printf("[*] %d chunk(s), embeddings by %s (%d computed)\n", stats.Chunks, embedder.Model, stats.Embedded)
+	} else {
+		fmt.Printf("[*] %d chunk(s), keyword search only\n", stats.Chunks)
+	}
+	fmt.Printf("[*] Saved to %s\n", index.Dir)
+	return nil
+}
+
+// openRepoRoot opens the repository containing the current directory and returns
+// its worktree root
+func openRepoRoot() (*git.Repository, string, error) {
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, "", fmt.Errorf("not a git repository: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get worktree: %w", err)
+	}
+	return repo, worktree.Filesystem.Root(), nil
+}
+
+// configuredEmbedder returns the embedder set up with ZW_EMBEDDINGS_URL, or nil
+func configuredEmbedder() *index.Embedder {
+	cfg := config.DefaultConfig()
+	if cfg.EmbeddingsURL == "" {
+		return nil
+	}
+	return index.NewEmbedder(cfg.EmbeddingsURL, cfg.EmbeddingsModel, cfg.EmbeddingsKey)
+}
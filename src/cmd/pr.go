@@ -0,0 +1,413 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"zero-workflow/src/internal/gitutil"
+	"zero-workflow/src/internal/handlers"
+	"zero-workflow/src/internal/patch"
+	"zero-workflow/src/pkg/errors"
+)
+
+// prTemplatePaths are the locations GitHub looks for a pull request template
+var prTemplatePaths = []string{
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE.md",
+	"pull_request_template.md",
+	"PULL_REQUEST_TEMPLATE.md",
+	"docs/pull_request_template.md",
+	"docs/PULL_REQUEST_TEMPLATE.md",
+}
+
+var (
+	prBase     string
+	prLang     string
+	prWrite    string
+	prTemplate string
+	prOutput   string
+)
+
+var prCmd = &cobra.Command{
+	Use:   "pr",
+	Short: "Generate a pull request title and description",
+	Long: `Compares the current branch with its base branch and generates a pull request
+title and Markdown description (summary, changes, testing notes) from the commit
+log and diff. If the repository has a pull request template, it is filled in.
+
+Everything is computed from the local repository; GitHub is never contacted.
+
+Examples:
+  zw pr
+  zw pr --base develop
+  zw pr --write pr.md
+  zw pr -o json | jq -r .body`,
+	Args: cobra.NoArgs,
+	RunE: runPR,
+}
+
+func init() {
+	rootCmd.AddCommand(prCmd)
+	prCmd.Flags().StringVarP(&prBase, "base", "b", "", "Base branch or revision (default: origin/HEAD, main or master)")
+	prCmd.Flags().StringVarP(&prLang, "lang", "l", "en", "Language of the description (ru, en, uk, kz)")
+	prCmd.Flags().StringVarP(&prWrite, "write", "w", "", "Write the result to a file instead of stdout")
+	prCmd.Flags().StringVar(&prTemplate, "template", "", "Pull request template to fill (default: detected in the repository)")
+	prCmd.Flags().StringVarP(&prOutput, "output", "o", "text", "Output format (text, json)")
+}
+
+// prReport is the machine-readable result of "zw pr --output json"
+type prReport struct {
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Base      string     `json:"base"`
+	Head      string     `json:"head"`
+	MergeBase string     `json:"merge_base"`
+	Commits   []string   `json:"commits"`
+	Files     []fileStat `json:"files"`
+	Template  string     `json:"template,omitempty"`
+}
+
+func runPR(cmd *cobra.Command, args []string) error {
+	if prOutput != "text" && prOutput != "json" {
+		return fmt.Errorf("unsupported output format: %s. Supported: text, json", prOutput)
+	}
+	if !isValidLanguage(prLang) {
+		return fmt.Errorf("unsupported language: %s. Supported: ru, en, uk, kz", prLang)
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return fmt.Errorf("not a git repository: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	root := worktree.Filesystem.Root()
+
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+
+	baseName, baseCommit, err := resolveBaseBranch(repo, prBase)
+	if err != nil {
+		return err
+	}
+
+	bases, err := headCommit.MergeBase(baseCommit)
+	if err != nil {
+		return fmt.Errorf("failed to compute merge base: %w", err)
+	}
+	if len(bases) == 0 {
+		return fmt.Errorf("%s and HEAD have no common history", baseName)
+	}
+	mergeBase := bases[0]
+
+	commits, err := branchCommits(headCommit, mergeBase)
+	if err != nil {
+		return err
+	}
+
+	diff, err := getRangeDiff(mergeBase.Hash.String(), head.Hash().String())
+	if err != nil {
+		return err
+	}
+	files := patch.Parse(diff)
+	if len(files) == 0 && len(commits) == 0 {
+		return fmt.Errorf("no changes between %s and HEAD", baseName)
+	}
+
+	templatePath, template, err := findPRTemplate(root, prTemplate)
+	if err != nil {
+		return err
+	}
+
+	headName := "HEAD"
+	if head.Name().IsBranch() {
+		headName = head.Name().Short()
+	}
+
+	report := &prReport{
+		Base:      baseName,
+		Head:      headName,
+		MergeBase: mergeBase.Hash.String(),
+		Commits:   []string{},
+		Files:     []fileStat{},
+		Template:  templatePath,
+	}
+	for _, commit := range commits {
+		report.Commits = append(report.Commits, commit.Hash.String()[:8]+" "+firstLine(commit.Message))
+	}
+	for _, file := range files {
+		report.Files = append(report.Files, fileStat{Path: file.Path(), Insertions: file.Added(), Deletions: file.Removed(), Binary: file.Binary})
+	}
+
+	// Redact before the spinner starts so the warning is not overdrawn
+	prompt, err := redactPrompt(buildPRPrompt(report, commits, patch.Budget(files, diffLimit), template))
+	if err != nil {
+		return err
+	}
+
+	// The spinner draws on stdout, so only use it when a person is watching
+	var response string
+	if prOutput == "text" && prWrite == "" && term.IsTerminal(int(os.Stdout.Fd())) {
+		spinnerHandler := handlers.NewSpinnerHandler("Writing pull request description")
+		err = spinnerHandler.WithSpinner(func() error {
+			response, err = chatCompletion(prompt)
+			return err
+		})
+	} else {
+		response, err = chatCompletion(prompt)
+	}
+	if err != nil {
+		return err
+	}
+
+	report.Title, report.Body = parsePRDescription(response)
+	if report.Title == "" {
+		return fmt.Errorf("failed to parse AI response")
+	}
+
+	var output []byte
+	if prOutput == "json" {
+		if output, err = json.MarshalIndent(report, "", "  "); err != nil {
+			return err
+		}
+		output = append(output, '\n')
+	} else {
+		output = []byte(report.Title + "\n\n" + report.Body + "\n")
+	}
+
+	if prWrite != "" {
+		if err := os.WriteFile(prWrite, output, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", prWrite, err)
+		}
+		color.Green("✓ Pull request description written to %s", prWrite)
+		return nil
+	}
+
+	_, err = os.Stdout.Write(output)
+	return err
+}
+
+// resolveBaseBranch resolves --base, or detects the base from origin/HEAD, main or master
+func resolveBaseBranch(repo *git.Repository, base string) (string, *object.Commit, error) {
+	if base != "" {
+		hash, err := repo.ResolveRevision(plumbing.Revision(base))
+		if err != nil {
+			return "", nil, fmt.Errorf("unknown base %q: %w", base, err)
+		}
+		commit, err := repo.CommitObject(*hash)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read base commit: %w", err)
+		}
+		return base, commit, nil
+	}
+
+	var candidates []plumbing.ReferenceName
+	if ref, err := repo.Storer.Reference(plumbing.NewRemoteHEADReferenceName("origin")); err == nil && ref.Type() == plumbing.SymbolicReference {
+		candidates = append(candidates, ref.Target())
+	}
+	candidates = append(candidates,
+		plumbing.NewRemoteReferenceName("origin", "main"),
+		plumbing.NewRemoteReferenceName("origin", "master"),
+		plumbing.NewBranchReferenceName("main"),
+		plumbing.NewBranchReferenceName("master"),
+	)
+
+	for _, name := range candidates {
+		ref, err := repo.Reference(name, true)
+		if err != nil {
+			continue
+		}
+		commit, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			continue
+		}
+		return name.Short(), commit, nil
+	}
+
+	return "", nil, fmt.Errorf("could not detect the base branch (no origin/HEAD, main or master); use --base")
+}
+
+// branchCommits returns the non-merge commits of head that are not reachable from
+// the merge base, oldest first, like "git log --no-merges base..head", so that base
+// history merged into the branch is left out
+func branchCommits(head, mergeBase *object.Commit) ([]*object.Commit, error) {
+	reachable, err := gitutil.CommitRange(mergeBase, head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branch commits: %w", err)
+	}
+
+	var commits []*object.Commit
+	for i := len(reachable) - 1; i >= 0; i-- {
+		if reachable[i].NumParents() <= 1 {
+			commits = append(commits, reachable[i])
+		}
+	}
+	return commits, nil
+}
+
+// findPRTemplate returns the explicit template, or the first one found in the
+// repository (including the first file of .github/PULL_REQUEST_TEMPLATE/)
+func findPRTemplate(root, explicit string) (string, string, error) {
+	if explicit != "" {
+		data, err := os.ReadFile(explicit)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read template: %w", err)
+		}
+		return explicit, string(data), nil
+	}
+
+	candidates := append([]string{}, prTemplatePaths...)
+	if dir, err := os.ReadDir(filepath.Join(root, ".github", "PULL_REQUEST_TEMPLATE")); err == nil {
+		var names []string
+		for _, entry := range dir {
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".md") {
+				names = append(names, entry.Name())
+			}
+		}
+		sort.Strings(names)
+		if len(names) > 0 {
+			candidates = append(candidates, filepath.Join(".github", "PULL_REQUEST_TEMPLATE", names[0]))
+		}
+	}
+
+	for _, rel := range candidates {
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err == nil && strings.TrimSpace(string(data)) != "" {
+			return filepath.ToSlash(rel), string(data), nil
+		}
+	}
+	return "", "", nil
+}
+
+func buildPRPrompt(report *prReport, commits []*object.Commit, diff, template string) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Write the pull request title and description in %s.\n\n", languageName(prLang)))
+	builder.WriteString(fmt.Sprintf("Write a pull request for branch %q, to be merged into %q.\n\n", report.Head, report.Base))
+
+	builder.WriteString("Commits (oldest first):\n")
+	for _, commit := range commits {
+		builder.WriteString("- " + firstLine(commit.Message) + "\n")
+		_, body, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n\n")
+		if body = strings.TrimSpace(body); body != "" {
+			if len(body) > 500 {
+				body = body[:500] + "..."
+			}
+			builder.WriteString("  " + strings.ReplaceAll(body, "\n", "\n  ") + "\n")
+		}
+	}
+
+	builder.WriteString("\nChanged files:\n")
+	for _, file := range report.Files {
+		if file.Binary {
+			builder.WriteString(fmt.Sprintf("- %s (binary)\n", file.Path))
+		} else {
+			builder.WriteString(fmt.Sprintf("- %s (+%d -%d)\n", file.Path, file.Insertions, file.Deletions))
+		}
+	}
+
+	builder.WriteString(fmt.Sprintf("\nDiff:\n%s\n", diff))
+
+	requirements := []string{
+		"Title: a single line under 72 characters that says what the change does",
+		"Body: Markdown",
+		"Explain what changed and why; group related changes instead of listing every file",
+		"Do not invent facts that are not supported by the commits or the diff",
+	}
+	if template == "" {
+		requirements = append(requirements,
+			"Use the sections \"## Summary\" (1-3 sentences), \"## Changes\" (bullet list) and \"## Testing\" (how the change can be verified)",
+		)
+	} else {
+		requirements = append(requirements,
+			"Fill in the pull request template below: keep its headings, order and checklists, and replace its comments and placeholders with content",
+			"Leave checklist items unchecked unless the changes clearly satisfy them",
+		)
+	}
+
+	builder.WriteString("Requirements:\n")
+	for i, requirement := range requirements {
+		builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, requirement))
+	}
+
+	if template != "" {
+		builder.WriteString("\nPull request template:\n" + template + "\n")
+	}
+
+	builder.WriteString("\nReturn in this exact format:\nTitle on the first line\n\nMarkdown body")
+	return builder.String()
+}
+
+// parsePRDescription splits the response into title and body, tolerating a
+// surrounding code fence, a "Title:" label or a Markdown heading on the title
+func parsePRDescription(response string) (string, string) {
+	response = strings.TrimSpace(response)
+	if strings.HasPrefix(response, "```") && strings.HasSuffix(response, "```") {
+		response = strings.TrimSpace(strings.TrimSuffix(response, "```"))
+		if _, rest, ok := strings.Cut(response, "\n"); ok {
+			response = strings.TrimSpace(rest)
+		} else {
+			response = ""
+		}
+	}
+
+	title, body, _ := strings.Cut(response, "\n")
+	title = strings.TrimSpace(strings.TrimLeft(title, "# "))
+	for _, label := range []string{"Title:", "**Title:**"} {
+		if strings.HasPrefix(title, label) {
+			title = strings.TrimSpace(strings.TrimPrefix(title, label))
+		}
+	}
+	title = strings.Trim(title, "*`\"")
+	return title, strings.TrimSpace(body)
+}
+
+// getRangeDiff returns the full diff between two revisions
+func getRangeDiff(from, to string) (string, error) {
+	args := []string{"--no-color", from, to}
+	if err := errors.ValidateGitCommand("diff", args); err != nil {
+		return "", fmt.Errorf("git command validation failed: %w", err)
+	}
+
+	output, err := exec.Command("git", append([]string{"diff"}, args...)...).Output()
+	if err != nil {
+		return "", errors.NewGitError("diff", args, "failed to get diff", err)
+	}
+	return string(output), nil
+}
+
+func languageName(lang string) string {
+	switch lang {
+	case "ru":
+		return "Russian"
+	case "uk":
+		return "Ukrainian"
+	case "kz":
+		return "Kazakh"
+	default:
+		return "English"
+	}
+}
+
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return strings.TrimSpace(line)
+}
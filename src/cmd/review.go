@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"zero-workflow/src/internal/handlers"
+	"zero-workflow/src/internal/patch"
+	"zero-workflow/src/internal/renderer"
+	"zero-workflow/src/internal/review"
+	"zero-workflow/src/pkg/errors"
+)
+
+var (
+	reviewBase   string
+	reviewLang   string
+	reviewOutput string
+)
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Review staged or branch changes with AI",
+	Long: `Sends the staged changes (or, with --base, the changes of the current branch)
+to the AI with a code reviewer prompt and reports findings grouped by file and
+line, each with a severity (error, warning, info) and a category.
+
+Findings can be exported as JSON or SARIF for editors and code scanning tools.
+
+Examples:
+  zw review
+  zw review --base main
+  zw review -o sarif > review.sarif`,
+	Args: cobra.NoArgs,
+	RunE: runReview,
+}
+
+func init() {
+	rootCmd.AddCommand(reviewCmd)
+	reviewCmd.Flags().StringVarP(&reviewBase, "base", "b", "", "Review the branch against this base instead of the staged changes")
+	reviewCmd.Flags().StringVarP(&reviewLang, "lang", "l", "en", "Language of the findings (ru, en, uk, kz)")
+	reviewCmd.Flags().StringVarP(&reviewOutput, "output", "o", "text", "Output format (text, json, sarif)")
+}
+
+func runReview(cmd *cobra.Command, args []string) error {
+	if reviewOutput != "text" && reviewOutput != "json" && reviewOutput != "sarif" {
+		return fmt.Errorf("unsupported output format: %s. Supported: text, json, sarif", reviewOutput)
+	}
+	if !isValidLanguage(reviewLang) {
+		return fmt.Errorf("unsupported language: %s. Supported: ru, en, uk, kz", reviewLang)
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return fmt.Errorf("not a git repository: %w", err)
+	}
+
+	target := "staged changes"
+	var diff string
+	if reviewBase != "" {
+		if target, diff, err = branchDiff(repo, reviewBase); err != nil {
+			return err
+		}
+	} else if diff, err = getStagedPatch(); err != nil {
+		return err
+	}
+
+	files := patch.Parse(diff)
+	if len(files) == 0 {
+		if reviewBase != "" {
+			return fmt.Errorf("no changes between %s and HEAD", reviewBase)
+		}
+		return fmt.Errorf("no staged changes; stage files with git add or review a branch with --base")
+	}
+
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path())
+	}
+
+	// Redact before the spinner starts so the warning is not overdrawn
+	prompt, err := redactPrompt(buildReviewPrompt(target, paths, patch.Budget(files, diffLimit)))
+	if err != nil {
+		return err
+	}
+
+	// The spinner draws on stdout, so only use it when a person is watching
+	var response string
+	if reviewOutput == "text" && term.IsTerminal(int(os.Stdout.Fd())) {
+		spinnerHandler := handlers.NewSpinnerHandler("Reviewing " + target)
+		err = spinnerHandler.WithSpinner(func() error {
+			response, err = chatCompletion(prompt)
+			return err
+		})
+	} else {
+		response, err = chatCompletion(prompt)
+	}
+	if err != nil {
+		return err
+	}
+
+	report, err := review.Parse(response)
+	if err != nil {
+		return fmt.Errorf("failed to parse AI response: %w", err)
+	}
+	report.Target = target
+	if dropped := report.Normalize(paths); dropped > 0 {
+		color.New(color.FgYellow).Fprintf(os.Stderr, "Warning: ignored %d finding(s) outside the changed files\n", dropped)
+	}
+
+	switch reviewOutput {
+	case "json":
+		return writeJSON(report)
+	case "sarif":
+		output, err := report.SARIF(buildVersion)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(append(output, '\n'))
+		return err
+	}
+
+	fmt.Println(renderer.NewMarkdownRenderer().RenderMarkdown(report.Markdown()))
+	return nil
+}
+
+// branchDiff returns the diff of HEAD against its merge base with base
+func branchDiff(repo *git.Repository, base string) (string, string, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+
+	baseName, baseCommit, err := resolveBaseBranch(repo, base)
+	if err != nil {
+		return "", "", err
+	}
+	bases, err := headCommit.MergeBase(baseCommit)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to compute merge base: %w", err)
+	}
+	if len(bases) == 0 {
+		return "", "", fmt.Errorf("%s and HEAD have no common history", baseName)
+	}
+
+	diff, err := getRangeDiff(bases[0].Hash.String(), head.Hash().String())
+	if err != nil {
+		return "", "", err
+	}
+	return baseName + "...HEAD", diff, nil
+}
+
+// getStagedPatch returns the complete staged diff; budgeting is left to the caller
+func getStagedPatch() (string, error) {
+	args := stagedArgs("", "--no-color")
+	if err := errors.ValidateGitCommand("diff", args); err != nil {
+		return "", fmt.Errorf("git command validation failed: %w", err)
+	}
+
+	output, err := exec.Command("git", append([]string{"diff"}, args...)...).Output()
+	if err != nil {
+		return "", errors.NewGitError("diff", args, "failed to get staged diff", err)
+	}
+	return string(output), nil
+}
+
+func buildReviewPrompt(target string, paths []string, diff string) string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("You are an experienced code reviewer. Review the following %s and report real problems: bugs, security issues, performance problems, missing error handling and maintainability issues.\n\n", target))
+	builder.WriteString(fmt.Sprintf("Write summary, title, message and suggestion in %s.\n\n", languageName(reviewLang)))
+
+	builder.WriteString("Changed files:\n")
+	for _, path := range paths {
+		builder.WriteString("- " + path + "\n")
+	}
+	builder.WriteString("\nDiff:\n```diff\n" + diff + "\n```\n\n")
+
+	builder.WriteString(`Respond with a single JSON object and nothing else:
+{
+  "summary": "one or two sentences about the change as a whole",
+  "findings": [
+    {
+      "file": "path exactly as in the diff",
+      "line": 42,
+      "end_line": 45,
+      "severity": "error | warning | info",
+      "category": "` + strings.Join(review.Categories, " | ") + `",
+      "title": "short title",
+      "message": "what is wrong and why it matters",
+      "suggestion": "how to fix it (optional)"
+    }
+  ]
+}
+
+Requirements:
+1. line and end_line are line numbers in the new version of the file; compute them from the @@ hunk headers
+2. Only comment on lines added or changed in the diff
+3. error: will break or is exploitable; warning: likely problem; info: worthwhile improvement
+4. Do not praise, restate the change or comment on formatting a formatter would fix
+5. Return an empty findings list if there is nothing worth reporting`)
+
+	return builder.String()
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"zero-workflow/src/internal/commitmsg"
+	"zero-workflow/src/internal/gitutil"
+	"zero-workflow/src/internal/patch"
+	"zero-workflow/src/pkg/errors"
+)
+
+var rewordCmd = &cobra.Command{
+	Use:   "reword <rev>",
+	Short: "Regenerate the message of an existing commit",
+	Long: `Generates a new message for an earlier commit from its diff and rewrites the
+commits after it, keeping their content, authors and messages.
+
+Only commits on the current branch that have not been pushed to any remote
+branch can be reworded; merge commits are not supported.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReword,
+}
+
+func init() {
+	rootCmd.AddCommand(rewordCmd)
+	addMessageFlags(rewordCmd)
+}
+
+func runReword(cmd *cobra.Command, args []string) error {
+	interactive := !commitYes && !commitDryRun && term.IsTerminal(int(os.Stdin.Fd()))
+	dryRun := commitDryRun || (!commitYes && !interactive)
+	if !commitYes && !commitDryRun && !interactive {
+		fmt.Fprintln(os.Stderr, "stdin is not a terminal; showing the message only (use --yes to rewrite)")
+	}
+
+	out := io.Writer(os.Stdout)
+	if dryRun {
+		out = os.Stderr
+	}
+
+	repo, err := git.PlainOpen(".")
+	if err != nil {
+		return fmt.Errorf("not a git repository: %w", err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(args[0]))
+	if err != nil {
+		return fmt.Errorf("unknown revision %q: %w", args[0], err)
+	}
+
+	// Check everything that could prevent the rewrite before asking the model
+	path, err := gitutil.AncestryPath(repo, *hash)
+	if err != nil {
+		return err
+	}
+	target := path[0]
+
+	published, err := gitutil.PublishedIn(repo, target)
+	if err != nil {
+		return fmt.Errorf("failed to check remote branches: %w", err)
+	}
+	if published != "" {
+		return fmt.Errorf("commit %s is already in %s; rewording it would rewrite published history", target.Hash.String()[:8], published)
+	}
+
+	base := gitutil.EmptyTree
+	if target.NumParents() == 1 {
+		base = target.ParentHashes[0].String()
+	}
+	files, diff, err := getCommitChanges(base, target.Hash.String())
+	if err != nil {
+		return err
+	}
+
+	setup, err := prepareCommit(cmd, repo, worktree.Filesystem.Root(), files, diff)
+	if err != nil {
+		return err
+	}
+	setup.assemble.Trailers = append(commitmsg.MessageTrailers(target.Message), setup.assemble.Trailers...)
+
+	signer, err := commitSigner(setup)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "%s %s\n", color.CyanString("Rewording"), target.Hash.String()[:8])
+	fmt.Fprintln(out, color.CyanString("Current message:"))
+	for _, line := range strings.Split(strings.TrimSpace(target.Message), "\n") {
+		fmt.Fprintf(out, "  %s\n", color.HiBlackString(line))
+	}
+
+	var message string
+	for {
+		options, err := generateWithProgress(setup.request, interactive)
+		if err != nil {
+			return fmt.Errorf("failed to generate commit messages: %w", err)
+		}
+		message = commitmsg.Assemble(options[0].Title, options[0].Description, setup.assemble)
+
+		title, description, _ := strings.Cut(message, "\n\n")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, color.CyanString("Generated commit message:"))
+		fmt.Fprintf(out, "%s %s\n", color.GreenString("→"), color.WhiteString(title))
+		if description != "" {
+			for _, line := range strings.Split(description, "\n") {
+				fmt.Fprintf(out, "  %s\n", color.HiBlackString(line))
+			}
+		}
+
+		if !interactive {
+			break
+		}
+
+		fmt.Printf("\nRewrite %d commit(s)? (y/N/r to regenerate): ", len(path))
+		reader := bufio.NewReader(os.Stdin)
+		confirm, _ := reader.ReadString('\n')
+		confirm = strings.TrimSpace(strings.ToLower(confirm))
+
+		if confirm == "y" || confirm == "yes" {
+			break
+		}
+		if confirm == "r" {
+			continue
+		}
+
+		color.Yellow("Reword cancelled.")
+		return nil
+	}
+
+	if dryRun {
+		fmt.Println(message)
+		return nil
+	}
+
+	tip, err := gitutil.RewriteMessage(repo, path, message, setup.identity.Committer, signer)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, color.GreenString("✓ Reworded %s, rewrote %d commit(s); HEAD is now %s", target.Hash.String()[:8], len(path), tip.String()[:8]))
+	return nil
+}
+
+// getCommitChanges returns the files and the diff between two revisions
+func getCommitChanges(from, to string) ([]string, string, error) {
+	nameArgs := []string{"--name-only", from, to}
+	if err := errors.ValidateGitCommand("diff", nameArgs); err != nil {
+		return nil, "", fmt.Errorf("git command validation failed: %w", err)
+	}
+
+	output, err := exec.Command("git", append([]string{"diff"}, nameArgs...)...).Output()
+	if err != nil {
+		return nil, "", errors.NewGitError("diff", nameArgs, "failed to list changed files", err)
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+
+	diff, err := getRangeDiff(from, to)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return files, patch.Budget(patch.Parse(diff), diffLimit), nil
+}
@@ -0,0 +1,768 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+	"zero-workflow/src/internal/commitmsg"
+	"zero-workflow/src/internal/gitutil"
+	"zero-workflow/src/internal/handlers"
+	"zero-workflow/src/internal/patch"
+	"zero-workflow/src/pkg/errors"
+)
+
+const (
+	// splitUnitLimit and splitDiffLimit bound how much of each change and of the
+	// whole working tree is shown to the model when planning a split
+	splitUnitLimit = 2000
+	splitDiffLimit = 12000
+
+	// splitPreviewLines is how much of an untracked file is shown to the model
+	splitPreviewLines = 40
+
+	splitLeftoverTitle = "chore: update remaining changes"
+)
+
+// splitUnit is the smallest change the planner can move between commits:
+// a single hunk, or a whole file when it cannot be staged hunk by hunk
+type splitUnit struct {
+	ID        string
+	Path      string
+	File      *patch.File // nil for untracked files
+	Hunk      *patch.Hunk // nil when the whole file is one unit
+	Untracked bool
+}
+
+// Label describes the unit in plans shown to the user
+func (u *splitUnit) Label() string {
+	switch {
+	case u.Untracked:
+		return u.Path + " (untracked)"
+	case u.Hunk != nil:
+		return fmt.Sprintf("%s %s (+%d -%d)", u.Path, u.Hunk.Range(), u.Hunk.Added(), u.Hunk.Removed())
+	case u.File.New:
+		return u.Path + " (new file)"
+	case u.File.Deleted:
+		return u.Path + " (deleted)"
+	case u.File.Binary:
+		return u.Path + " (binary)"
+	case u.File.Mode:
+		return u.Path + " (mode change)"
+	}
+	return u.Path
+}
+
+// splitGroup is one planned commit; Hunks holds unit ids
+type splitGroup struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Hunks       []string `json:"hunks"`
+}
+
+// splitReport is the machine-readable result of "zw commit --split --output json"
+type splitReport struct {
+	Commits   []splitCommit `json:"commits"`
+	Committed bool          `json:"committed"`
+	Pushed    bool          `json:"pushed,omitempty"`
+}
+
+// splitCommit is a planned (and possibly created) commit of a split
+type splitCommit struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Message     string   `json:"message"`
+	Hunks       []string `json:"hunks"`
+	Files       []string `json:"files"`
+	Commit      string   `json:"commit,omitempty"`
+}
+
+// runCommitSplit implements "zw commit --split": it plans several commits from all
+// working-tree changes and stages and commits them one by one. The working tree
+// itself is never modified; only the index and the branch move.
+func runCommitSplit(cmd *cobra.Command, interactive, dryRun, jsonOutput bool, out io.Writer) error {
+	repo, err := git.PlainOpen(".")
+	if err != nil {
+		return fmt.Errorf("not a git repository: %w", err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	root := worktree.Filesystem.Root()
+
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("--split needs at least one commit to compare against: %w", err)
+	}
+
+	units, err := collectSplitUnits(worktree)
+	if err != nil {
+		return err
+	}
+
+	report := &splitReport{Commits: []splitCommit{}}
+	if len(units) == 0 {
+		if jsonOutput {
+			return writeJSON(report)
+		}
+		fmt.Fprintln(out, color.YellowString("No changes found in the working tree."))
+		return nil
+	}
+
+	byID := make(map[string]*splitUnit, len(units))
+	fmt.Fprintln(out, color.CyanString("Changes:"))
+	for _, unit := range units {
+		byID[unit.ID] = unit
+		fmt.Fprintf(out, "  %s %s\n", color.GreenString(unit.ID), unit.Label())
+	}
+	fmt.Fprintln(out)
+
+	setup, err := prepareCommit(cmd, repo, root, splitPaths(units, nil), describeSplitUnits(root, units))
+	if err != nil {
+		return err
+	}
+
+	// Resolve signing before generating so misconfiguration fails fast
+	signer, err := commitSigner(setup)
+	if err != nil {
+		return err
+	}
+
+	// Redact before the spinner starts so the warning is not overdrawn
+	prompt, err := splitPrompt(setup)
+	if err != nil {
+		return err
+	}
+
+	var groups []splitGroup
+	regenerate := true
+plan:
+	for {
+		if regenerate {
+			if interactive {
+				spinnerHandler := handlers.NewSpinnerHandler("Planning commits with AI")
+				err = spinnerHandler.WithSpinner(func() error {
+					groups, err = generateSplitPlan(setup, root, units, prompt)
+					return err
+				})
+			} else {
+				groups, err = generateSplitPlan(setup, root, units, prompt)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to plan commits: %w", err)
+			}
+			regenerate = false
+		}
+
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, color.CyanString("Proposed commits:"))
+		printSplitPlan(out, groups, byID)
+
+		if !interactive {
+			break
+		}
+
+		fmt.Printf("\nCreate %d commit(s)? (y/N/e to edit/r to regenerate): ", len(groups))
+		reader := bufio.NewReader(os.Stdin)
+		confirm, _ := reader.ReadString('\n')
+
+		switch strings.TrimSpace(strings.ToLower(confirm)) {
+		case "y", "yes":
+			break plan
+		case "r":
+			regenerate = true
+		case "e":
+			edited, err := editSplitPlan(setup.gitConfig, groups, units, byID)
+			if err != nil {
+				color.Red("Plan not changed: %v", err)
+				continue
+			}
+			if len(edited) == 0 {
+				color.Yellow("Commit cancelled.")
+				return nil
+			}
+			groups = edited
+		default:
+			color.Yellow("Commit cancelled.")
+			return nil
+		}
+	}
+
+	for _, group := range groups {
+		report.Commits = append(report.Commits, splitCommit{
+			Title:       group.Title,
+			Description: group.Description,
+			Message:     commitmsg.Assemble(group.Title, group.Description, setup.assemble),
+			Hunks:       group.Hunks,
+			Files:       splitPaths(units, group.Hunks),
+		})
+	}
+
+	if dryRun {
+		if jsonOutput {
+			return writeJSON(report)
+		}
+		fmt.Print(formatSplitPlan(groups, byID))
+		return nil
+	}
+
+	// The index is restored, and HEAD moved back, if any commit cannot be made
+	original, err := repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to read the index: %w", err)
+	}
+	rollback := func(cause error) error {
+		if err := worktree.Reset(&git.ResetOptions{Commit: head.Hash(), Mode: git.SoftReset}); err != nil {
+			return fmt.Errorf("%w; also failed to move HEAD back to %s: %v", cause, head.Hash().String()[:8], err)
+		}
+		if err := repo.Storer.SetIndex(original); err != nil {
+			return fmt.Errorf("%w; also failed to restore the index: %v", cause, err)
+		}
+		return fmt.Errorf("%w; nothing was committed", cause)
+	}
+
+	// Start from HEAD so that only the planned hunks end up in each commit
+	if err := worktree.Reset(&git.ResetOptions{Commit: head.Hash(), Mode: git.MixedReset}); err != nil {
+		return rollback(fmt.Errorf("failed to reset the index: %w", err))
+	}
+
+	// Every group must apply before the first commit is made
+	for i, planned := range report.Commits {
+		if err := applyToIndex(root, splitGroupPatch(planned.Hunks, byID), true); err != nil {
+			return rollback(fmt.Errorf("commit %d of %d (%s) does not apply: %w", i+1, len(report.Commits), planned.Title, err))
+		}
+	}
+
+	for i := range report.Commits {
+		planned := &report.Commits[i]
+		if err := stageSplitGroup(worktree, root, planned.Hunks, byID); err != nil {
+			return rollback(fmt.Errorf("failed to stage commit %d of %d (%s): %w", i+1, len(report.Commits), planned.Title, err))
+		}
+
+		commitOptions := &git.CommitOptions{
+			Author:    &setup.identity.Author,
+			Committer: &setup.identity.Committer,
+		}
+		if signer != nil {
+			commitOptions.Signer = signer
+		}
+
+		commit, err := worktree.Commit(planned.Message, commitOptions)
+		if err != nil {
+			return rollback(fmt.Errorf("failed to create commit %d of %d (%s): %w", i+1, len(report.Commits), planned.Title, err))
+		}
+
+		planned.Commit = commit.String()
+		fmt.Fprintln(out, color.GreenString("✓ %s %s", commit.String()[:8], planned.Title))
+	}
+	report.Committed = true
+
+	if autoPush {
+		if destination, err := pushToRemote(repo); err != nil {
+			fmt.Fprintln(out, color.YellowString("Warning: Failed to push: %v", err))
+		} else {
+			report.Pushed = true
+			fmt.Fprintln(out, color.GreenString("✓ Pushed to %s", destination))
+		}
+	}
+
+	if jsonOutput {
+		return writeJSON(report)
+	}
+	return nil
+}
+
+// collectSplitUnits lists every change between HEAD and the working tree:
+// one unit per hunk of modified text files, one per other changed or untracked file
+func collectSplitUnits(worktree *git.Worktree) ([]*splitUnit, error) {
+	diff, err := getWorkingTreeDiff(worktree.Filesystem.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	var units []*splitUnit
+	add := func(unit *splitUnit) {
+		unit.ID = fmt.Sprintf("H%d", len(units)+1)
+		units = append(units, unit)
+	}
+
+	for _, file := range patch.Parse(diff) {
+		if file.Whole() {
+			add(&splitUnit{Path: file.Path(), File: file})
+			continue
+		}
+		for _, hunk := range file.Hunks {
+			add(&splitUnit{Path: file.Path(), File: file, Hunk: hunk})
+		}
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git status: %w", err)
+	}
+
+	var untracked []string
+	for path, fileStatus := range status {
+		if fileStatus.Worktree == git.Untracked {
+			untracked = append(untracked, path)
+		}
+	}
+	sort.Strings(untracked)
+	for _, path := range untracked {
+		add(&splitUnit{Path: path, Untracked: true})
+	}
+
+	return units, nil
+}
+
+// getWorkingTreeDiff returns the diff of tracked files between HEAD and the working tree
+func getWorkingTreeDiff(root string) (string, error) {
+	args := []string{"HEAD", "--no-renames", "--no-color"}
+	if err := errors.ValidateGitCommand("diff", args); err != nil {
+		return "", fmt.Errorf("git command validation failed: %w", err)
+	}
+
+	cmd := exec.Command("git", append([]string{"diff"}, args...)...)
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return "", errors.NewGitError("diff", args, "failed to get working tree diff", err)
+	}
+	return string(output), nil
+}
+
+// describeSplitUnits renders the changes for the model, each prefixed with its id
+func describeSplitUnits(root string, units []*splitUnit) string {
+	var builder strings.Builder
+	for _, unit := range units {
+		builder.WriteString(fmt.Sprintf("### %s %s\n", unit.ID, unit.Label()))
+		if builder.Len() > splitDiffLimit {
+			continue // keep listing ids so every unit can still be assigned
+		}
+
+		var body string
+		switch {
+		case unit.Untracked:
+			body = previewUntracked(filepath.Join(root, unit.Path))
+		case unit.Hunk != nil:
+			body = unit.Hunk.String()
+		case !unit.File.Binary:
+			body = unit.File.String()
+		}
+		if len(body) > splitUnitLimit {
+			body = body[:splitUnitLimit] + "\n... (truncated)\n"
+		}
+		builder.WriteString(body)
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// previewUntracked shows the beginning of a new file as added lines
+func previewUntracked(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data, 0) >= 0 {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	truncated := len(lines) > splitPreviewLines
+	if truncated {
+		lines = lines[:splitPreviewLines]
+	}
+
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString("+" + line + "\n")
+	}
+	if truncated {
+		builder.WriteString("... (truncated)\n")
+	}
+	return builder.String()
+}
+
+// splitPrompt builds the redacted prompt for generateSplitPlan
+func splitPrompt(setup *commitSetup) (string, error) {
+	if !isValidLanguage(setup.request.Lang) {
+		return "", fmt.Errorf("unsupported language: %s. Supported: ru, en, uk, kz", setup.request.Lang)
+	}
+	return redactPrompt(buildSplitPrompt(setup.request))
+}
+
+// generateSplitPlan asks the model to group the units into commits
+func generateSplitPlan(setup *commitSetup, root string, units []*splitUnit, prompt string) ([]splitGroup, error) {
+	response, err := chatCompletion(prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := parseSplitPlan(response)
+	if err != nil {
+		return nil, err
+	}
+
+	groups = normalizeSplitPlan(groups, units)
+
+	// Scopes are inferred per commit, from the files that commit touches
+	if commitScope == "" {
+		for i := range groups {
+			scope := commitmsg.InferScope(root, splitPaths(units, groups[i].Hunks), setup.repoConfig.Scope)
+			groups[i].Title = scope.Apply(groups[i].Title)
+		}
+	} else {
+		for i := range groups {
+			groups[i].Title = setup.request.Scope.Apply(groups[i].Title)
+		}
+	}
+
+	return groups, nil
+}
+
+// buildSplitPrompt asks for a JSON plan; request.Diff holds the id-labelled changes
+func buildSplitPrompt(request commitRequest) string {
+	conventional := request.Style == nil || request.Style.Samples == 0 || request.Style.Conventional >= 0.5
+
+	requirements := []string{
+		"Put every change id into exactly one commit",
+		"Group changes that belong to the same logical change; hunks of one file may go to different commits",
+		"Order commits so that each one builds on the previous ones",
+		"Prefer fewer commits: do not split a single logical change",
+	}
+	if conventional {
+		requirements = append(requirements, "Use conventional commit format for titles: type(scope): description")
+	}
+	if request.Style == nil || request.Style.Samples == 0 {
+		requirements = append(requirements, "Keep titles under 50 characters")
+	}
+	if request.Scope.Name != "" && commitScope != "" {
+		requirements = append(requirements, request.Scope.Constraint())
+	}
+
+	var builder strings.Builder
+	builder.WriteString(getLanguageInstructions(request.Lang))
+	builder.WriteString("\n\nThe working tree contains several changes that should not go into a single commit. ")
+	builder.WriteString("Split them into logical commits. Each change below starts with a line \"### <id> <file>\".\n\n")
+	builder.WriteString(fmt.Sprintf("Files changed: %s\n\n", strings.Join(request.Files, ", ")))
+	builder.WriteString(fmt.Sprintf("Changes:\n%s\n", request.Diff))
+
+	builder.WriteString("Requirements:\n")
+	for i, requirement := range requirements {
+		builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, requirement))
+	}
+
+	if description := request.Style.Describe(); description != "" {
+		builder.WriteString("\n" + description)
+	}
+	if examples := request.Style.FewShot(); examples != "" {
+		builder.WriteString("\n" + examples)
+	}
+
+	builder.WriteString("\nReturn only JSON in this exact format:\n")
+	builder.WriteString(`{"commits": [{"title": "`)
+	if conventional {
+		builder.WriteString("type(scope): short description")
+	} else {
+		builder.WriteString("Short title")
+	}
+	builder.WriteString(`", "description": "optional longer description", "hunks": ["H1", "H2"]}]}`)
+
+	return builder.String()
+}
+
+// parseSplitPlan extracts the JSON plan, tolerating code fences and surrounding prose
+func parseSplitPlan(response string) ([]splitGroup, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("failed to parse AI response: no JSON plan found")
+	}
+
+	var plan struct {
+		Commits []splitGroup `json:"commits"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	}
+	return plan.Commits, nil
+}
+
+// normalizeSplitPlan drops unknown and repeated ids and empty commits, sorts each
+// commit's units in diff order and collects anything left out into a final commit
+func normalizeSplitPlan(groups []splitGroup, units []*splitUnit) []splitGroup {
+	order := make(map[string]int, len(units))
+	for i, unit := range units {
+		order[unit.ID] = i
+	}
+
+	assigned := map[string]bool{}
+	var result []splitGroup
+	for _, group := range groups {
+		var ids []string
+		for _, id := range group.Hunks {
+			id = strings.ToUpper(strings.TrimSpace(id))
+			if _, ok := order[id]; ok && !assigned[id] {
+				assigned[id] = true
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		sort.Slice(ids, func(i, j int) bool { return order[ids[i]] < order[ids[j]] })
+
+		group.Title = strings.TrimSpace(group.Title)
+		if group.Title == "" {
+			group.Title = splitLeftoverTitle
+		}
+		group.Description = strings.TrimSpace(group.Description)
+		group.Hunks = ids
+		result = append(result, group)
+	}
+
+	var leftover []string
+	for _, unit := range units {
+		if !assigned[unit.ID] {
+			leftover = append(leftover, unit.ID)
+		}
+	}
+	if len(leftover) > 0 {
+		result = append(result, splitGroup{Title: splitLeftoverTitle, Hunks: leftover})
+	}
+
+	return result
+}
+
+// splitPaths returns the sorted distinct paths of the given unit ids, or of all units when ids is nil
+func splitPaths(units []*splitUnit, ids []string) []string {
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	seen := map[string]bool{}
+	var paths []string
+	for _, unit := range units {
+		if (ids == nil || wanted[unit.ID]) && !seen[unit.Path] {
+			seen[unit.Path] = true
+			paths = append(paths, unit.Path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func printSplitPlan(out io.Writer, groups []splitGroup, byID map[string]*splitUnit) {
+	for i, group := range groups {
+		fmt.Fprintf(out, "%s %s\n", color.GreenString("%d.", i+1), color.WhiteString(group.Title))
+		if group.Description != "" {
+			for _, line := range strings.Split(group.Description, "\n") {
+				fmt.Fprintf(out, "   %s\n", color.HiBlackString(line))
+			}
+		}
+		for _, id := range group.Hunks {
+			fmt.Fprintf(out, "   %s %s\n", color.CyanString(id), byID[id].Label())
+		}
+	}
+}
+
+// formatSplitPlan renders the plan in the text format used by the editor
+func formatSplitPlan(groups []splitGroup, byID map[string]*splitUnit) string {
+	var builder strings.Builder
+	for i, group := range groups {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(fmt.Sprintf("[%d] %s\n", i+1, group.Title))
+		if group.Description != "" {
+			for _, line := range strings.Split(group.Description, "\n") {
+				builder.WriteString("| " + line + "\n")
+			}
+		}
+		for _, id := range group.Hunks {
+			builder.WriteString(fmt.Sprintf("%s %s\n", id, byID[id].Label()))
+		}
+	}
+	return builder.String()
+}
+
+const splitPlanHelp = `
+# Edit the commit plan. Each commit starts with "[n] title"; lines starting
+# with "| " form its description and the following lines list change ids.
+# Move ids between commits, reorder or rename commits as you like.
+# Changes that are removed from the plan stay uncommitted in the working tree.
+# An empty plan cancels the split.
+`
+
+// editSplitPlan lets the user rework the plan in their editor
+func editSplitPlan(cfg *gitutil.Config, groups []splitGroup, units []*splitUnit, byID map[string]*splitUnit) ([]splitGroup, error) {
+	file, err := os.CreateTemp("", "zw-split-*.txt")
+	if err != nil {
+		return nil, err
+	}
+	path := file.Name()
+	defer os.Remove(path)
+
+	content := formatSplitPlan(groups, byID) + splitPlanHelp
+	var unplanned []string
+	planned := map[string]bool{}
+	for _, group := range groups {
+		for _, id := range group.Hunks {
+			planned[id] = true
+		}
+	}
+	for _, unit := range units {
+		if !planned[unit.ID] {
+			unplanned = append(unplanned, fmt.Sprintf("#   %s %s", unit.ID, unit.Label()))
+		}
+	}
+	if len(unplanned) > 0 {
+		content += "#\n# Not in any commit:\n" + strings.Join(unplanned, "\n") + "\n"
+	}
+
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := gitutil.RunEditor(cfg.Editor(), path); err != nil {
+		return nil, err
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseSplitPlanText(string(edited), units)
+}
+
+// parseSplitPlanText reads a plan written by formatSplitPlan and edited by the user
+func parseSplitPlanText(text string, units []*splitUnit) ([]splitGroup, error) {
+	order := make(map[string]int, len(units))
+	for i, unit := range units {
+		order[unit.ID] = i
+	}
+
+	var groups []splitGroup
+	used := map[string]bool{}
+	for number, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			end := strings.Index(trimmed, "]")
+			if end < 0 || strings.TrimSpace(trimmed[end+1:]) == "" {
+				return nil, fmt.Errorf("line %d: expected \"[n] title\"", number+1)
+			}
+			groups = append(groups, splitGroup{Title: strings.TrimSpace(trimmed[end+1:])})
+		case len(groups) == 0:
+			return nil, fmt.Errorf("line %d: %q appears before the first commit title", number+1, trimmed)
+		case strings.HasPrefix(trimmed, "|"):
+			group := &groups[len(groups)-1]
+			description := strings.TrimPrefix(strings.TrimPrefix(trimmed, "|"), " ")
+			if group.Description != "" {
+				group.Description += "\n"
+			}
+			group.Description += description
+		default:
+			id := strings.ToUpper(strings.Fields(trimmed)[0])
+			if _, ok := order[id]; !ok {
+				return nil, fmt.Errorf("line %d: unknown change id %q", number+1, id)
+			}
+			if used[id] {
+				return nil, fmt.Errorf("line %d: %s is listed more than once", number+1, id)
+			}
+			used[id] = true
+			group := &groups[len(groups)-1]
+			group.Hunks = append(group.Hunks, id)
+		}
+	}
+
+	var result []splitGroup
+	for _, group := range groups {
+		if len(group.Hunks) == 0 {
+			continue
+		}
+		sort.Slice(group.Hunks, func(i, j int) bool { return order[group.Hunks[i]] < order[group.Hunks[j]] })
+		group.Description = strings.TrimSpace(group.Description)
+		result = append(result, group)
+	}
+	return result, nil
+}
+
+// stageSplitGroup adds one commit's changes to the index: selected hunks through
+// "git apply --cached" and whole files through go-git
+func stageSplitGroup(worktree *git.Worktree, root string, ids []string, byID map[string]*splitUnit) error {
+	for _, id := range ids {
+		if unit := byID[id]; unit.Hunk == nil {
+			if _, err := worktree.Add(unit.Path); err != nil {
+				return fmt.Errorf("failed to stage %s: %w", unit.Path, err)
+			}
+		}
+	}
+	return applyToIndex(root, splitGroupPatch(ids, byID), false)
+}
+
+// splitGroupPatch renders the selected hunks of one commit as a patch, "" when
+// the commit only has whole files
+func splitGroupPatch(ids []string, byID map[string]*splitUnit) string {
+	var files []*patch.File
+	hunks := map[*patch.File][]*patch.Hunk{}
+
+	for _, id := range ids {
+		unit := byID[id]
+		if unit.Hunk == nil {
+			continue
+		}
+		if _, ok := hunks[unit.File]; !ok {
+			files = append(files, unit.File)
+		}
+		hunks[unit.File] = append(hunks[unit.File], unit.Hunk)
+	}
+
+	var diff strings.Builder
+	for _, file := range files {
+		diff.WriteString(file.Render(hunks[file]))
+	}
+	return diff.String()
+}
+
+// applyToIndex applies a patch to the index only, leaving the working tree alone.
+// With check, it only tells whether the patch applies.
+func applyToIndex(root, diff string, check bool) error {
+	if diff == "" {
+		return nil
+	}
+	args := []string{"--cached", "--recount", "-"}
+	if check {
+		args = append([]string{"--check"}, args...)
+	}
+	if err := errors.ValidateGitCommand("apply", args); err != nil {
+		return fmt.Errorf("git command validation failed: %w", err)
+	}
+
+	// Patch paths are relative to the top of the repository
+	cmd := exec.Command("git", append([]string{"apply"}, args...)...)
+	cmd.Dir = root
+	cmd.Stdin = strings.NewReader(diff)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.NewGitError("apply", args, "failed to stage hunks", fmt.Errorf("%s", strings.TrimSpace(string(output))))
+	}
+	return nil
+}
@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"zero-workflow/src/internal/gitutil"
+	"zero-workflow/src/internal/handlers"
+	"zero-workflow/src/internal/semver"
+)
+
+// buildVersion is set from main, which receives it through -ldflags at release time
+var buildVersion = "dev"
+
+// SetVersion records the version zw was built as
+func SetVersion(version string) {
+	buildVersion = version
+	rootCmd.Version = version
+}
+
+var (
+	versionCreate         bool
+	versionMessage        string
+	versionNoAI           bool
+	versionOutput         string
+	versionMajorZeroMinor bool
+)
+
+// versionLevelLine matches one "<number>: <level>" line of the AI classification
+var versionLevelLine = regexp.MustCompile(`(?i)^\s*(\d+)\s*[.:)-]\s*(major|minor|patch|none)\b`)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the zw version or suggest the next release version",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(buildVersion)
+	},
+}
+
+var versionNextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "Suggest the next semantic version from commits since the last tag",
+	Long: `Finds the last semantic version tag reachable from HEAD and applies the
+Conventional Commits rules to the commits since then:
+
+  BREAKING CHANGE or "!"  major (minor before 1.0.0 with --major-zero-minor)
+  feat                    minor
+  fix and other changes   patch
+
+Commits that do not follow Conventional Commits are classified by AI
+(disable with --no-ai, in which case they count as patch).
+
+The next version is printed on stdout, the reasoning on stderr.
+
+Examples:
+  zw version next
+  zw version next --create
+  git tag "$(zw version next --no-ai)"`,
+	Args: cobra.NoArgs,
+	RunE: runVersionNext,
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+	versionCmd.AddCommand(versionNextCmd)
+	versionNextCmd.Flags().BoolVarP(&versionCreate, "create", "c", false, "Create an annotated tag for the next version at HEAD")
+	versionNextCmd.Flags().StringVarP(&versionMessage, "message", "m", "", "Tag message (default: \"Release <version>\")")
+	versionNextCmd.Flags().BoolVar(&versionNoAI, "no-ai", false, "Do not ask AI about non-conventional commits; count them as patch")
+	versionNextCmd.Flags().StringVarP(&versionOutput, "output", "o", "text", "Output format (text, json)")
+	versionNextCmd.Flags().BoolVar(&versionMajorZeroMinor, "major-zero-minor", false, "Bump the minor version for breaking changes before 1.0.0")
+}
+
+// versionReport is the machine-readable result of "zw version next --output json"
+type versionReport struct {
+	Current string          `json:"current,omitempty"`
+	Next    string          `json:"next"`
+	Bump    string          `json:"bump"`
+	Commits []versionCommit `json:"commits"`
+	Created bool            `json:"created"`
+}
+
+// versionCommit records why a commit contributed to the bump
+type versionCommit struct {
+	Hash   string `json:"hash"`
+	Title  string `json:"title"`
+	Bump   string `json:"bump"`
+	Source string `json:"source"` // conventional, ai or default
+}
+
+func runVersionNext(cmd *cobra.Command, args []string) error {
+	if versionOutput != "text" && versionOutput != "json" {
+		return fmt.Errorf("unsupported output format: %s. Supported: text, json", versionOutput)
+	}
+
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return fmt.Errorf("not a git repository: %w", err)
+	}
+	headRef, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	head, err := repo.CommitObject(headRef.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+
+	tags, err := gitutil.TagsByCommit(repo)
+	if err != nil {
+		return fmt.Errorf("failed to look up tags: %w", err)
+	}
+	if current, ok := latestRelease(tags[head.Hash]); ok {
+		return fmt.Errorf("HEAD is already tagged %s", current)
+	}
+
+	_, from, err := gitutil.NearestTag(repo, head, isReleaseTag)
+	if err != nil {
+		return fmt.Errorf("failed to look up tags: %w", err)
+	}
+
+	// Without a previous release the first one is computed from 0.0.0
+	current := semver.Version{Prefix: "v"}
+	report := &versionReport{}
+	if from != nil {
+		current, _ = latestRelease(tags[from.Hash])
+		report.Current = current.String()
+	}
+
+	commits, err := gitutil.CommitRange(from, head)
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var unknown []int
+	var messages []string
+	for _, commit := range commits {
+		if commit.NumParents() > 1 {
+			continue
+		}
+		entry := versionCommit{Hash: commit.Hash.String()[:7], Title: firstLine(commit.Message), Source: "conventional"}
+		level, ok := semver.CommitLevel(commit.Message)
+		if !ok {
+			level, entry.Source = semver.Patch, "default"
+			unknown = append(unknown, len(report.Commits))
+			messages = append(messages, commit.Message)
+		}
+		entry.Bump = level.String()
+		report.Commits = append(report.Commits, entry)
+	}
+	if len(report.Commits) == 0 {
+		return fmt.Errorf("no commits since %s", current)
+	}
+
+	if len(unknown) > 0 && !versionNoAI {
+		levels, err := classifyCommitsWithAI(messages)
+		if err != nil {
+			color.New(color.FgYellow).Fprintf(os.Stderr, "Warning: AI classification failed, counting %d non-conventional commit(s) as patch: %v\n", len(unknown), err)
+		}
+		for i, index := range unknown {
+			if level, ok := levels[i]; ok {
+				report.Commits[index].Bump = level.String()
+				report.Commits[index].Source = "ai"
+			}
+		}
+	}
+
+	bump := semver.None
+	for _, commit := range report.Commits {
+		if level, _ := semver.ParseLevel(commit.Bump); level > bump {
+			bump = level
+		}
+	}
+	// Commits only touching docs or tooling still make a new tag a patch release
+	if bump == semver.None {
+		bump = semver.Patch
+	}
+	// Before 1.0.0 the public API may be considered unstable, see --major-zero-minor
+	if versionMajorZeroMinor && bump == semver.Major && current.Major == 0 {
+		bump = semver.Minor
+	}
+
+	next := current.Bump(bump)
+	report.Next = next.String()
+	report.Bump = bump.String()
+
+	if versionCreate {
+		if _, err := repo.Tag(report.Next); err == nil {
+			return fmt.Errorf("tag %s already exists", report.Next)
+		}
+		if err := createReleaseTag(repo, head.Hash, report.Next); err != nil {
+			return err
+		}
+		report.Created = true
+	}
+
+	if versionOutput == "json" {
+		return writeJSON(report)
+	}
+
+	printVersionReasons(report)
+	fmt.Println(report.Next)
+	if report.Created {
+		color.New(color.FgGreen).Fprintf(os.Stderr, "✓ Created tag %s\n", report.Next)
+		fmt.Fprintf(os.Stderr, "Push it with: git push origin %s\n", report.Next)
+	}
+	return nil
+}
+
+// isReleaseTag accepts semantic version tags that are not prereleases
+func isReleaseTag(name string) bool {
+	version, ok := semver.Parse(name)
+	return ok && version.Prerelease == ""
+}
+
+// latestRelease returns the highest release version among tag names
+func latestRelease(names []string) (semver.Version, bool) {
+	var latest semver.Version
+	found := false
+	for _, name := range names {
+		if !isReleaseTag(name) {
+			continue
+		}
+		version, _ := semver.Parse(name)
+		if !found || version.Compare(latest) > 0 {
+			latest, found = version, true
+		}
+	}
+	return latest, found
+}
+
+// classifyCommitsWithAI asks the model for the bump level of each message,
+// keyed by the message's index; messages it did not answer for are left out
+func classifyCommitsWithAI(messages []string) (map[int]semver.Level, error) {
+	var builder strings.Builder
+	builder.WriteString("Classify each commit by the semantic version bump it requires for the users of the project.\n\n")
+	builder.WriteString("Commits:\n")
+	for i, message := range messages {
+		message = strings.TrimSpace(message)
+		if len(message) > 500 {
+			message = message[:500] + "..."
+		}
+		builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, strings.ReplaceAll(message, "\n", "\n   ")))
+	}
+	builder.WriteString("\nLevels:\n")
+	builder.WriteString("- major: breaking change for users\n")
+	builder.WriteString("- minor: new backwards-compatible functionality\n")
+	builder.WriteString("- patch: backwards-compatible bug fix or other change users may notice\n")
+	builder.WriteString("- none: documentation, tests or tooling only\n\n")
+	builder.WriteString("Answer with one line per commit in the form \"<number>: <level>\" and nothing else.")
+
+	// Redact before the spinner starts so the warning is not overdrawn
+	prompt, err := redactPrompt(builder.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var response string
+	if term.IsTerminal(int(os.Stdout.Fd())) && versionOutput != "json" {
+		spinnerHandler := handlers.NewSpinnerHandler("Classifying commits")
+		err = spinnerHandler.WithSpinner(func() error {
+			response, err = chatCompletion(prompt)
+			return err
+		})
+	} else {
+		response, err = chatCompletion(prompt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	levels := map[int]semver.Level{}
+	for _, line := range strings.Split(response, "\n") {
+		parts := versionLevelLine.FindStringSubmatch(line)
+		if parts == nil {
+			continue
+		}
+		n, err := strconv.Atoi(parts[1])
+		if err != nil || n < 1 || n > len(messages) {
+			continue
+		}
+		level, _ := semver.ParseLevel(strings.ToLower(parts[2]))
+		levels[n-1] = level
+	}
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("unexpected response: %s", firstLine(response))
+	}
+	return levels, nil
+}
+
+// createReleaseTag creates an annotated tag at target, tagged by the git committer identity
+func createReleaseTag(repo *git.Repository, target plumbing.Hash, name string) error {
+	gitConfig, err := gitutil.LoadConfig(repo)
+	if err != nil {
+		return fmt.Errorf("failed to read git config: %w", err)
+	}
+	identity, err := gitConfig.Identity()
+	if err != nil {
+		return err
+	}
+
+	message := versionMessage
+	if message == "" {
+		message = "Release " + name
+	}
+
+	if _, err := repo.CreateTag(name, target, &git.CreateTagOptions{
+		Tagger:  &identity.Committer,
+		Message: message,
+	}); err != nil {
+		return fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+	return nil
+}
+
+// printVersionReasons lists the commits behind the bump on stderr
+func printVersionReasons(report *versionReport) {
+	from := report.Current
+	if from == "" {
+		from = "(no release yet)"
+	}
+	fmt.Fprintf(os.Stderr, "%s → %s (%s, %d commit(s))\n", from, report.Next, report.Bump, len(report.Commits))
+	for _, commit := range report.Commits {
+		source := ""
+		if commit.Source != "conventional" {
+			source = " [" + commit.Source + "]"
+		}
+		fmt.Fprintf(os.Stderr, "  %-5s  %s %s%s\n", commit.Bump, commit.Hash, commit.Title, source)
+	}
+}
@@ -0,0 +1,261 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"zero-workflow/src/internal/commitmsg"
+)
+
+// Keep a Changelog sections, in the order they are rendered
+const (
+	Added      = "Added"
+	Changed    = "Changed"
+	Deprecated = "Deprecated"
+	Removed    = "Removed"
+	Fixed      = "Fixed"
+	Security   = "Security"
+)
+
+// Sections lists every section in rendering order
+var Sections = []string{Added, Changed, Deprecated, Removed, Fixed, Security}
+
+// Unreleased is the version name of changes that have not been tagged yet
+const Unreleased = "Unreleased"
+
+// Header starts a new changelog file
+const Header = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+`
+
+// typeSections maps Conventional Commit types to sections. Types mapped to ""
+// (documentation, tests, tooling) are not user-facing and are left out.
+var typeSections = map[string]string{
+	"feat":       Added,
+	"fix":        Fixed,
+	"perf":       Changed,
+	"refactor":   Changed,
+	"revert":     Changed,
+	"deprecate":  Deprecated,
+	"remove":     Removed,
+	"security":   Security,
+	"docs":       "",
+	"style":      "",
+	"test":       "",
+	"tests":      "",
+	"chore":      "",
+	"ci":         "",
+	"build":      "",
+	"release":    "",
+	"wip":        "",
+	"merge":      "",
+	"dependabot": "",
+}
+
+// linkReference matches the "[1.0.0]: https://..." lines at the end of a changelog
+var linkReference = regexp.MustCompile(`^\[[^\]]+\]:\s`)
+
+// Entry is a single changelog line
+type Entry struct {
+	Section  string
+	Scope    string
+	Text     string
+	Hash     string
+	Breaking bool
+}
+
+// String renders the entry as a Markdown list item
+func (e Entry) String() string {
+	var builder strings.Builder
+	builder.WriteString("- ")
+	if e.Breaking {
+		builder.WriteString("**BREAKING:** ")
+	}
+	if e.Scope != "" {
+		builder.WriteString("**" + e.Scope + ":** ")
+	}
+	builder.WriteString(e.Text)
+	if e.Hash != "" {
+		builder.WriteString(" (" + e.Hash + ")")
+	}
+	return builder.String()
+}
+
+// Classify turns a commit message into a changelog entry. ok is false for commits
+// that are not user-facing, such as documentation or CI changes, unless they are
+// marked as breaking. Messages that are not Conventional Commits go to Changed.
+func Classify(message, hash string) (Entry, bool) {
+	if strings.HasPrefix(message, "Merge ") {
+		return Entry{}, false
+	}
+
+	commit, conventional := commitmsg.ParseConventional(message)
+	if !conventional {
+		title, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+		if strings.TrimSpace(title) == "" {
+			return Entry{}, false
+		}
+		return Entry{Section: Changed, Text: capitalize(strings.TrimSpace(title)), Hash: hash}, true
+	}
+
+	section, known := typeSections[commit.Type]
+	if !known {
+		section = Changed
+	}
+	if section == Fixed && strings.EqualFold(commit.Scope, "security") {
+		section = Security
+	}
+	if section == "" {
+		if !commit.Breaking {
+			return Entry{}, false
+		}
+		section = Changed
+	}
+
+	return Entry{
+		Section:  section,
+		Scope:    commit.Scope,
+		Text:     capitalize(commit.Description),
+		Hash:     hash,
+		Breaking: commit.Breaking,
+	}, true
+}
+
+// Release is one version section of the changelog
+type Release struct {
+	Version string    // without a "v" prefix, or Unreleased
+	Date    time.Time // zero for unreleased changes
+	Entries []Entry
+}
+
+// Heading returns the "## [version] - date" line
+func (r *Release) Heading() string {
+	if r.Version == Unreleased || r.Date.IsZero() {
+		return fmt.Sprintf("## [%s]", r.Version)
+	}
+	return fmt.Sprintf("## [%s] - %s", r.Version, r.Date.Format("2006-01-02"))
+}
+
+// Body renders the entries grouped into "### Section" blocks
+func (r *Release) Body() string {
+	var blocks []string
+	for _, section := range Sections {
+		var lines []string
+		for _, entry := range r.Entries {
+			if entry.Section == section {
+				lines = append(lines, entry.String())
+			}
+		}
+		if len(lines) > 0 {
+			blocks = append(blocks, "### "+section+"\n\n"+strings.Join(lines, "\n"))
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// Render renders the heading followed by body, which is normally Body() but may
+// be rewritten release notes
+func (r *Release) Render(body string) string {
+	if strings.TrimSpace(body) == "" {
+		body = "No notable changes."
+	}
+	return r.Heading() + "\n\n" + strings.TrimSpace(body) + "\n"
+}
+
+// Update inserts a rendered release section into an existing changelog. A section
+// for the same version is replaced; when a version is released, the Unreleased
+// section it supersedes is removed. New sections go above the previous releases.
+func Update(existing, section, version string) string {
+	if strings.TrimSpace(existing) == "" {
+		return Header + "\n" + section
+	}
+
+	lines := strings.Split(strings.TrimRight(existing, "\n"), "\n")
+
+	// Link references at the very end stay there
+	footerStart := len(lines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if linkReference.MatchString(lines[i]) {
+			footerStart = i
+			continue
+		}
+		if strings.TrimSpace(lines[i]) != "" {
+			break
+		}
+	}
+	footer := lines[footerStart:]
+	lines = lines[:footerStart]
+
+	var preamble []string
+	var sections [][]string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "## ") {
+			sections = append(sections, []string{line})
+			continue
+		}
+		if len(sections) == 0 {
+			preamble = append(preamble, line)
+		} else {
+			sections[len(sections)-1] = append(sections[len(sections)-1], line)
+		}
+	}
+
+	var kept []string
+	for _, block := range sections {
+		name := sectionVersion(block[0])
+		if strings.EqualFold(name, version) || (version != Unreleased && strings.EqualFold(name, Unreleased)) {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(strings.Join(block, "\n"), "\n"))
+	}
+
+	var builder strings.Builder
+	if head := strings.TrimRight(strings.Join(preamble, "\n"), "\n"); strings.TrimSpace(head) != "" {
+		builder.WriteString(head + "\n\n")
+	}
+	builder.WriteString(strings.TrimRight(section, "\n"))
+	for _, block := range kept {
+		builder.WriteString("\n\n" + block)
+	}
+	builder.WriteString("\n")
+	if len(footer) > 0 {
+		builder.WriteString("\n" + strings.Join(footer, "\n") + "\n")
+	}
+	return builder.String()
+}
+
+// VersionFromTag turns a tag such as "v1.2.0" into the version used in headings
+func VersionFromTag(tag string) string {
+	if len(tag) > 1 && (tag[0] == 'v' || tag[0] == 'V') && tag[1] >= '0' && tag[1] <= '9' {
+		return tag[1:]
+	}
+	return tag
+}
+
+// sectionVersion extracts the version from "## [1.0.0] - 2024-01-01" or "## 1.0.0"
+func sectionVersion(heading string) string {
+	heading = strings.TrimSpace(strings.TrimPrefix(heading, "## "))
+	if strings.HasPrefix(heading, "[") {
+		if end := strings.Index(heading, "]"); end > 0 {
+			return heading[1:end]
+		}
+	}
+	name, _, _ := strings.Cut(heading, " ")
+	return VersionFromTag(name)
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
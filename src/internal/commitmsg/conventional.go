@@ -0,0 +1,42 @@
+package commitmsg
+
+import (
+	"strings"
+)
+
+// Conventional is a commit message parsed according to Conventional Commits
+type Conventional struct {
+	Type        string // lower-cased, e.g. "feat"
+	Scope       string
+	Description string
+	Body        string
+	Breaking    bool // "!" after the type/scope or a BREAKING CHANGE footer
+}
+
+// ParseConventional parses a full commit message. ok is false when the title
+// does not follow the "type(scope): description" form.
+func ParseConventional(message string) (Conventional, bool) {
+	message = strings.TrimSpace(message)
+	title, body, _ := strings.Cut(message, "\n")
+
+	parts := conventionalTitle.FindStringSubmatch(strings.TrimSpace(title))
+	if parts == nil {
+		return Conventional{}, false
+	}
+
+	commit := Conventional{
+		Type:        strings.ToLower(parts[1]),
+		Scope:       strings.Trim(parts[2], "()"),
+		Description: strings.TrimSpace(parts[4]),
+		Body:        strings.TrimSpace(body),
+		Breaking:    parts[3] == "!",
+	}
+
+	for _, line := range strings.Split(commit.Body, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			commit.Breaking = true
+		}
+	}
+
+	return commit, true
+}
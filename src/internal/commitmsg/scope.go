@@ -0,0 +1,191 @@
+package commitmsg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"zero-workflow/src/internal/config"
+)
+
+// conventionalTitle matches "type(scope)!: description"
+var conventionalTitle = regexp.MustCompile(`^([a-zA-Z]+)(\([^)]*\))?(!)?:\s*(.*)$`)
+
+// goMajorSuffix matches the /vN suffix of Go module paths
+var goMajorSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// Scope is the result of scope inference for a set of staged paths
+type Scope struct {
+	Name       string   // the single scope all paths agree on, empty otherwise
+	Candidates []string // distinct scopes found across paths, sorted
+}
+
+// Constraint returns the prompt instruction describing the inferred scope.
+// An empty string means the model is free to choose.
+func (s Scope) Constraint() string {
+	if s.Name != "" {
+		return fmt.Sprintf("Use exactly %q as the commit scope: type(%s): description", s.Name, s.Name)
+	}
+	if len(s.Candidates) > 1 {
+		return fmt.Sprintf("The changes span several scopes (%s); use the main one or omit the scope", strings.Join(s.Candidates, ", "))
+	}
+	return ""
+}
+
+// Apply rewrites the scope of a Conventional Commits title to the inferred one.
+// Without a single inferred scope, and for titles that are not in Conventional
+// Commits form, the title is returned unchanged.
+func (s Scope) Apply(title string) string {
+	if s.Name == "" {
+		return title
+	}
+
+	parts := conventionalTitle.FindStringSubmatch(title)
+	if parts == nil {
+		return title
+	}
+	return parts[1] + "(" + s.Name + ")" + parts[3] + ": " + parts[4]
+}
+
+// InferScope derives a Conventional Commits scope from paths relative to root
+func InferScope(root string, paths []string, cfg config.ScopeConfig) Scope {
+	if cfg.Strategy == config.ScopeStrategyNone {
+		return Scope{}
+	}
+
+	resolver := &scopeResolver{
+		root:     root,
+		cfg:      cfg,
+		packages: map[string]string{},
+	}
+
+	seen := map[string]bool{}
+	for _, p := range paths {
+		if scope := resolver.scopeFor(filepath.ToSlash(p)); scope != "" {
+			seen[scope] = true
+		}
+	}
+
+	candidates := make([]string, 0, len(seen))
+	for scope := range seen {
+		candidates = append(candidates, scope)
+	}
+	sort.Strings(candidates)
+
+	result := Scope{Candidates: candidates}
+	if len(candidates) == 1 {
+		result.Name = candidates[0]
+	}
+	return result
+}
+
+// scopeResolver maps individual paths to scopes, caching manifest lookups
+type scopeResolver struct {
+	root     string
+	cfg      config.ScopeConfig
+	packages map[string]string // directory -> package scope ("" when none)
+}
+
+func (r *scopeResolver) scopeFor(p string) string {
+	switch r.cfg.Strategy {
+	case config.ScopeStrategyMap:
+		return r.fromMap(p)
+	case config.ScopeStrategyPackage:
+		return r.fromPackage(p)
+	case config.ScopeStrategyTopLevel:
+		return fromTopLevel(p)
+	}
+
+	// Top-level directories are only used when asked for: in a single-root layout
+	// such as src/ they would give almost every commit the same scope
+	if scope := r.fromMap(p); scope != "" {
+		return scope
+	}
+	return r.fromPackage(p)
+}
+
+// fromMap returns the scope of the longest configured prefix containing p
+func (r *scopeResolver) fromMap(p string) string {
+	best := ""
+	scope := ""
+	for prefix, name := range r.cfg.Map {
+		if (p == prefix || strings.HasPrefix(p, prefix+"/")) && len(prefix) > len(best) {
+			best = prefix
+			scope = name
+		}
+	}
+	return scope
+}
+
+// fromPackage returns the name of the nearest nested go.mod or package.json.
+// Manifests at the repository root are ignored since they describe the whole repo.
+func (r *scopeResolver) fromPackage(p string) string {
+	for dir := path.Dir(p); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		scope, cached := r.packages[dir]
+		if !cached {
+			scope = r.readManifest(dir)
+			r.packages[dir] = scope
+		}
+		if scope != "" {
+			return scope
+		}
+	}
+	return ""
+}
+
+// readManifest returns the package name declared in dir, if any
+func (r *scopeResolver) readManifest(dir string) string {
+	abs := filepath.Join(r.root, filepath.FromSlash(dir))
+
+	if data, err := os.ReadFile(filepath.Join(abs, "go.mod")); err == nil {
+		if module := goModulePath(string(data)); module != "" {
+			elems := strings.Split(module, "/")
+			name := elems[len(elems)-1]
+			if goMajorSuffix.MatchString(name) && len(elems) > 1 {
+				name = elems[len(elems)-2]
+			}
+			return name
+		}
+		return path.Base(dir)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(abs, "package.json")); err == nil {
+		var manifest struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(data, &manifest) == nil && manifest.Name != "" {
+			// Drop the npm organisation: @acme/web -> web
+			if i := strings.LastIndex(manifest.Name, "/"); i >= 0 {
+				return manifest.Name[i+1:]
+			}
+			return manifest.Name
+		}
+		return path.Base(dir)
+	}
+
+	return ""
+}
+
+// goModulePath extracts the module path from go.mod content
+func goModulePath(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+		}
+	}
+	return ""
+}
+
+// fromTopLevel returns the first directory of p; root-level files have no scope
+func fromTopLevel(p string) string {
+	if i := strings.Index(p, "/"); i > 0 {
+		return p[:i]
+	}
+	return ""
+}
@@ -0,0 +1,317 @@
+package commitmsg
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ticketPattern matches issue keys such as PROJ-1234
+var ticketPattern = regexp.MustCompile(`\b([A-Z][A-Z0-9]+)-[0-9]+\b`)
+
+// Ticket placements observed in commit messages
+const (
+	TicketPrefix = "prefix"
+	TicketSuffix = "suffix"
+	TicketBody   = "body"
+)
+
+// Style describes the conventions inferred from a sample of commit messages
+type Style struct {
+	Samples         int
+	Conventional    float64  // share of titles in Conventional Commits form
+	Language        string   // "en", "ru", "uk", "kz" or "" when unclear
+	LowercaseStart  bool     // descriptions usually start with a lowercase letter
+	TrailingPeriod  bool     // titles usually end with a period
+	TitleLength     int      // 90th percentile title length
+	Types           []string // conventional types by frequency
+	Scopes          []string // conventional scopes by frequency
+	TicketKey       string   // most common issue project key, e.g. "PROJ"
+	TicketPlacement string   // one of the Ticket* values
+	BodyRatio       float64  // share of messages with a body
+	BulletBody      bool     // bodies are usually bullet lists
+	Examples        []string // recent messages to use as few-shot examples
+}
+
+// AnalyzeStyle infers commit conventions from messages ordered newest first
+func AnalyzeStyle(messages []string, examples int) *Style {
+	style := &Style{}
+
+	var (
+		titleLengths []int
+		conventional int
+		lowercase    int
+		cased        int
+		period       int
+		bodies       int
+		bullets      int
+		types        = map[string]int{}
+		scopes       = map[string]int{}
+		tickets      = map[string]int{}
+		placements   = map[string]int{}
+		letters      strings.Builder
+	)
+
+	for _, message := range messages {
+		message = strings.TrimSpace(message)
+		if message == "" {
+			continue
+		}
+		style.Samples++
+
+		title, body, _ := strings.Cut(message, "\n")
+		title = strings.TrimSpace(title)
+		body = strings.TrimSpace(body)
+
+		titleLengths = append(titleLengths, len([]rune(title)))
+
+		if strings.HasSuffix(title, ".") {
+			period++
+		}
+
+		description := title
+		if parts := conventionalTitle.FindStringSubmatch(title); parts != nil {
+			conventional++
+			types[strings.ToLower(parts[1])]++
+			if scope := strings.Trim(parts[2], "()"); scope != "" {
+				scopes[scope]++
+			}
+			description = parts[4]
+		}
+		// The type prefix is always Latin, so only the description reveals the language
+		letters.WriteString(description)
+		letters.WriteString(" ")
+		if first := firstLetter(description); first != 0 {
+			cased++
+			if unicode.IsLower(first) {
+				lowercase++
+			}
+		}
+
+		if body != "" {
+			bodies++
+			if isBulletList(body) {
+				bullets++
+			}
+		}
+
+		if placement, key := ticketPlacement(title, body); key != "" {
+			tickets[key]++
+			placements[placement]++
+		}
+
+		if len(style.Examples) < examples {
+			style.Examples = append(style.Examples, message)
+		}
+	}
+
+	if style.Samples == 0 {
+		return style
+	}
+
+	samples := float64(style.Samples)
+	style.Conventional = float64(conventional) / samples
+	style.TrailingPeriod = float64(period)/samples > 0.5
+	style.LowercaseStart = cased > 0 && float64(lowercase)/float64(cased) > 0.5
+	style.BodyRatio = float64(bodies) / samples
+	style.BulletBody = bodies > 0 && float64(bullets)/float64(bodies) > 0.5
+	style.TitleLength = percentile(titleLengths, 0.9)
+	style.Types = byFrequency(types)
+	style.Scopes = byFrequency(scopes)
+	style.Language = detectLanguage(letters.String())
+
+	if keys := byFrequency(tickets); len(keys) > 0 && float64(sumCounts(tickets))/samples >= 0.3 {
+		style.TicketKey = keys[0]
+		style.TicketPlacement = byFrequency(placements)[0]
+	}
+
+	return style
+}
+
+// Describe renders the inferred conventions as prompt instructions
+func (s *Style) Describe() string {
+	if s == nil || s.Samples == 0 {
+		return ""
+	}
+
+	var rules []string
+	if s.Conventional >= 0.5 {
+		if len(s.Types) > 0 {
+			rules = append(rules, fmt.Sprintf("Commit types used in this repository: %s", strings.Join(s.Types, ", ")))
+		}
+		if len(s.Scopes) > 0 {
+			rules = append(rules, fmt.Sprintf("Prefer existing scopes: %s", strings.Join(limit(s.Scopes, 15), ", ")))
+		}
+	} else {
+		rules = append(rules, "This repository does not use Conventional Commits; match the title format of the examples instead")
+	}
+
+	if s.LowercaseStart {
+		rules = append(rules, "Start the description with a lowercase letter")
+	} else {
+		rules = append(rules, "Start the description with a capital letter")
+	}
+	if s.TrailingPeriod {
+		rules = append(rules, "End the title with a period")
+	} else {
+		rules = append(rules, "Do not end the title with a period")
+	}
+	if s.TitleLength > 0 {
+		// Short samples would make the limit unrealistically tight
+		rules = append(rules, fmt.Sprintf("Keep the title under %d characters", max(s.TitleLength, 50)))
+	}
+
+	switch {
+	case s.BodyRatio < 0.2:
+		rules = append(rules, "Usually write a title only, without a description")
+	case s.BulletBody:
+		rules = append(rules, "Write the description as a bullet list using \"- \"")
+	default:
+		rules = append(rules, "Write the description as short prose paragraphs")
+	}
+
+	if s.TicketKey != "" {
+		switch s.TicketPlacement {
+		case TicketPrefix:
+			rules = append(rules, fmt.Sprintf("Titles start with an issue key like %s-123 when one is known", s.TicketKey))
+		case TicketSuffix:
+			rules = append(rules, fmt.Sprintf("Titles end with an issue key like %s-123 when one is known", s.TicketKey))
+		default:
+			rules = append(rules, fmt.Sprintf("Issue keys like %s-123 are referenced in the description", s.TicketKey))
+		}
+		rules = append(rules, "Never invent issue keys")
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Repository conventions learned from the last %d commits (they take precedence over the generic requirements):\n", s.Samples))
+	for _, rule := range rules {
+		builder.WriteString("- " + rule + "\n")
+	}
+	return builder.String()
+}
+
+// FewShot renders the example messages for inclusion in the prompt
+func (s *Style) FewShot() string {
+	if s == nil || len(s.Examples) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("Recent commit messages from this repository:\n")
+	for _, example := range s.Examples {
+		builder.WriteString("---\n")
+		builder.WriteString(example)
+		builder.WriteString("\n")
+	}
+	builder.WriteString("---\n")
+	return builder.String()
+}
+
+// ticketPlacement reports where an issue key appears in a message
+func ticketPlacement(title, body string) (string, string) {
+	if loc := ticketPattern.FindStringSubmatchIndex(title); loc != nil {
+		key := title[loc[2]:loc[3]]
+		if strings.TrimLeft(title[:loc[0]], "[( ") == "" {
+			return TicketPrefix, key
+		}
+		return TicketSuffix, key
+	}
+	if match := ticketPattern.FindStringSubmatch(body); match != nil {
+		return TicketBody, match[1]
+	}
+	return "", ""
+}
+
+// detectLanguage guesses the commit language from its script
+func detectLanguage(text string) string {
+	var latin, cyrillic int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		}
+	}
+
+	if latin+cyrillic == 0 {
+		return ""
+	}
+	if latin >= cyrillic {
+		return "en"
+	}
+
+	lower := strings.ToLower(text)
+	if strings.ContainsAny(lower, "әғқңөұүһ") {
+		return "kz"
+	}
+	if strings.ContainsAny(lower, "іїєґ") {
+		return "uk"
+	}
+	return "ru"
+}
+
+func isBulletList(body string) bool {
+	lines, bulleted := 0, 0
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		lines++
+		if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
+			bulleted++
+		}
+	}
+	return lines > 0 && bulleted*2 >= lines
+}
+
+func firstLetter(s string) rune {
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return r
+		}
+	}
+	return 0
+}
+
+func percentile(values []int, p float64) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	return sorted[int(float64(len(sorted)-1)*p)]
+}
+
+// byFrequency returns keys ordered by descending count, then alphabetically
+func byFrequency(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	return total
+}
+
+func limit(values []string, n int) []string {
+	if len(values) > n {
+		return values[:n]
+	}
+	return values
+}
@@ -0,0 +1,156 @@
+package commitmsg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Issue placements for keys extracted from the branch name
+const (
+	IssuePrefix  = "prefix"
+	IssueTrailer = "trailer"
+	IssueNone    = "none"
+)
+
+// trailerLine matches a git trailer such as "Signed-off-by: Name <email>"
+var trailerLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s+(.+)$`)
+
+// Trailer is a single "Key: value" line at the end of a commit message
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+func (t Trailer) String() string {
+	return t.Key + ": " + t.Value
+}
+
+// ParseTrailer parses a "Key: value" string
+func ParseTrailer(s string) (Trailer, error) {
+	parts := trailerLine.FindStringSubmatch(strings.TrimSpace(s))
+	if parts == nil {
+		return Trailer{}, fmt.Errorf("invalid trailer %q, expected \"Key: value\"", s)
+	}
+	return Trailer{Key: parts[1], Value: strings.TrimSpace(parts[2])}, nil
+}
+
+// ExtractIssues returns the distinct issue keys found in a branch name.
+// If the pattern has a capture group, the first group is used as the key.
+func ExtractIssues(branch string, pattern *regexp.Regexp) []string {
+	if pattern == nil || branch == "" {
+		return nil
+	}
+
+	var issues []string
+	seen := map[string]bool{}
+	for _, match := range pattern.FindAllStringSubmatch(branch, -1) {
+		key := match[0]
+		if len(match) > 1 && match[1] != "" {
+			key = match[1]
+		}
+		if !seen[key] {
+			seen[key] = true
+			issues = append(issues, key)
+		}
+	}
+	return issues
+}
+
+// AssembleOptions describes what to add around the generated title and description
+type AssembleOptions struct {
+	Issues         []string
+	IssuePlacement string // one of the Issue* values
+	IssueTrailer   string // trailer key used with IssueTrailer placement
+	Trailers       []Trailer
+}
+
+// Assemble builds the final commit message from a title, description and options.
+// Issue keys and trailers already present in the message are not duplicated.
+func Assemble(title, description string, opts AssembleOptions) string {
+	title = strings.TrimSpace(title)
+	description = strings.TrimSpace(description)
+
+	var trailers []Trailer
+	switch opts.IssuePlacement {
+	case IssuePrefix:
+		var missing []string
+		for _, issue := range opts.Issues {
+			if !strings.Contains(title, issue) {
+				missing = append(missing, issue)
+			}
+		}
+		if len(missing) > 0 {
+			title = strings.Join(missing, " ") + " " + title
+		}
+	case IssueTrailer:
+		key := opts.IssueTrailer
+		if key == "" {
+			key = "Refs"
+		}
+		for _, issue := range opts.Issues {
+			trailers = append(trailers, Trailer{Key: key, Value: issue})
+		}
+	}
+	trailers = append(trailers, opts.Trailers...)
+
+	// Split off an existing trailer block so new trailers join it
+	body, existing := splitTrailers(description)
+
+	present := map[string]bool{}
+	for _, line := range existing {
+		present[strings.ToLower(line)] = true
+	}
+	for _, trailer := range trailers {
+		line := trailer.String()
+		if !present[strings.ToLower(line)] {
+			present[strings.ToLower(line)] = true
+			existing = append(existing, line)
+		}
+	}
+
+	message := title
+	if body != "" {
+		message += "\n\n" + body
+	}
+	if len(existing) > 0 {
+		message += "\n\n" + strings.Join(existing, "\n")
+	}
+	return message
+}
+
+// MessageTrailers returns the trailers at the end of a full commit message
+func MessageTrailers(message string) []Trailer {
+	_, rest, _ := strings.Cut(strings.TrimSpace(message), "\n\n")
+	_, lines := splitTrailers(strings.TrimSpace(rest))
+
+	var trailers []Trailer
+	for _, line := range lines {
+		if trailer, err := ParseTrailer(line); err == nil {
+			trailers = append(trailers, trailer)
+		}
+	}
+	return trailers
+}
+
+// splitTrailers separates a trailing paragraph made only of trailers from the body
+func splitTrailers(description string) (string, []string) {
+	if description == "" {
+		return "", nil
+	}
+
+	paragraphs := strings.Split(description, "\n\n")
+	last := strings.Split(strings.TrimSpace(paragraphs[len(paragraphs)-1]), "\n")
+	for _, line := range last {
+		if !trailerLine.MatchString(strings.TrimSpace(line)) {
+			return description, nil
+		}
+	}
+
+	var trailers []string
+	for _, line := range last {
+		trailers = append(trailers, strings.TrimSpace(line))
+	}
+	body := strings.TrimSpace(strings.Join(paragraphs[:len(paragraphs)-1], "\n\n"))
+	return body, trailers
+}
@@ -18,6 +18,11 @@ type Config struct {
 	Provider       string // "zai" or "custom"
 	CustomAPIKey   string // for custom API
 	CustomEndpoint string // for custom API
+
+	// OpenAI-compatible embeddings endpoint used by zw index; empty when not configured
+	EmbeddingsURL   string
+	EmbeddingsModel string
+	EmbeddingsKey   string
 }
 
 // AIParams holds AI-specific parameters
@@ -70,14 +75,26 @@ func DefaultConfig() *Config {
 		userAgent = "Mozilla/5.0 (X11; Linux x86_64; rv:140.0) Gecko/20100101 Firefox/140.0"
 	}
 
+	embeddingsModel := os.Getenv("ZW_EMBEDDINGS_MODEL")
+	if embeddingsModel == "" {
+		embeddingsModel = "text-embedding-3-small"
+	}
+	embeddingsKey := os.Getenv("ZW_EMBEDDINGS_API_KEY")
+	if embeddingsKey == "" {
+		embeddingsKey = os.Getenv("ZW_CUSTOM_API_KEY")
+	}
+
 	return &Config{
-		APIBaseURL:     apiURL,
-		UserAgent:      userAgent,
-		Timeout:        120 * time.Second,
-		Model:          model,
-		Provider:       provider,
-		CustomAPIKey:   os.Getenv("ZW_CUSTOM_API_KEY"),
-		CustomEndpoint: apiURL,
+		APIBaseURL:      apiURL,
+		UserAgent:       userAgent,
+		Timeout:         120 * time.Second,
+		Model:           model,
+		Provider:        provider,
+		CustomAPIKey:    os.Getenv("ZW_CUSTOM_API_KEY"),
+		CustomEndpoint:  apiURL,
+		EmbeddingsURL:   os.Getenv("ZW_EMBEDDINGS_URL"),
+		EmbeddingsModel: embeddingsModel,
+		EmbeddingsKey:   embeddingsKey,
 	}
 }
 
@@ -0,0 +1,336 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+)
+
+// RepoConfigPath is the per-repository settings file, relative to the work tree root.
+// It uses git-config syntax so teams can commit it alongside their code.
+const RepoConfigPath = ".zw/config"
+
+// Scope inference strategies
+const (
+	ScopeStrategyAuto     = "auto"
+	ScopeStrategyMap      = "map"
+	ScopeStrategyPackage  = "package"
+	ScopeStrategyTopLevel = "toplevel"
+	ScopeStrategyNone     = "none"
+)
+
+// DefaultIssuePattern matches issue keys such as PROJ-1234 in branch names
+const DefaultIssuePattern = `[A-Z][A-Z0-9]+-[0-9]+`
+
+// RepoConfig holds per-repository settings
+type RepoConfig struct {
+	Scope    ScopeConfig
+	Style    StyleConfig
+	Issue    IssueConfig
+	Trailers []string // extra trailers such as "Signed-off-by: {name} <{email}>"
+	Redact   RedactConfig
+	Files    FilesConfig
+}
+
+// ScopeConfig controls how commit scopes are derived from staged paths
+type ScopeConfig struct {
+	Strategy string            // one of the ScopeStrategy* values
+	Map      map[string]string // path prefix -> scope name
+}
+
+// StyleConfig controls learning commit conventions from history
+type StyleConfig struct {
+	History  int // number of recent commits to sample, 0 disables
+	Examples int // number of sampled messages used as few-shot examples
+}
+
+// IssueConfig controls extracting issue keys from the current branch name
+type IssueConfig struct {
+	Pattern   *regexp.Regexp
+	Placement string // "prefix", "trailer" or "none"
+	Trailer   string // trailer key used with the "trailer" placement
+}
+
+// RedactConfig controls removing secrets from prompts before they are sent
+type RedactConfig struct {
+	Enabled bool
+	Disable []string         // built-in detectors to turn off
+	Allow   []*regexp.Regexp // matches that are never redacted
+	Deny    []*regexp.Regexp // extra patterns that are always redacted
+}
+
+// FilesConfig controls which files zw ask may read
+type FilesConfig struct {
+	Roots        []string // directories files may be read from, besides the repository
+	Extensions   []string // if set, only files with these extensions or names
+	Deny         []string // extensions or file names that are refused
+	Hidden       string   // "deny" or "allow"
+	AllowHidden  []string // patterns of hidden paths allowed with Hidden "deny"
+	MaxFileSize  int64
+	MaxTotalSize int64
+	MaxFiles     int
+}
+
+// DefaultRepoConfig returns settings used when no .zw/config exists
+func DefaultRepoConfig() *RepoConfig {
+	return &RepoConfig{
+		Scope: ScopeConfig{
+			Strategy: ScopeStrategyAuto,
+			Map:      map[string]string{},
+		},
+		Style: StyleConfig{
+			History:  0,
+			Examples: 5,
+		},
+		Issue: IssueConfig{
+			Pattern:   regexp.MustCompile(DefaultIssuePattern),
+			Placement: "trailer",
+			Trailer:   "Refs",
+		},
+		Redact: RedactConfig{
+			Enabled: true,
+		},
+		Files: FilesConfig{
+			Deny:   []string{".exe", ".bat", ".cmd", ".com", ".scr", ".pif", ".vbs"},
+			Hidden: "deny",
+			AllowHidden: []string{
+				".env", ".env.*", ".gitignore", ".gitattributes", ".dockerignore", ".editorconfig",
+				".github/**", ".gitlab-ci.yml", ".golangci.yml", ".golangci.yaml", ".zw/config",
+			},
+			MaxFileSize:  1024 * 1024,
+			MaxTotalSize: 5 * 1024 * 1024,
+			MaxFiles:     100,
+		},
+	}
+}
+
+// LoadRepoConfig loads .zw/config from the given work tree root.
+// A missing file is not an error; defaults are returned instead.
+//
+// Example:
+//
+//	[scope]
+//		strategy = auto
+//	[scope "src/internal/config"]
+//		name = config
+//	[style]
+//		history = 50
+//	[issue]
+//		pattern = ([A-Z]+-[0-9]+)
+//		placement = prefix
+//	[trailer]
+//		add = Signed-off-by: {name} <{email}>
+//	[redact]
+//		disable = high-entropy
+//		allow = EXAMPLE$
+//		deny = internal-[0-9a-f]{32}
+//	[files]
+//		root = ../shared
+//		deny = .exe, .dll
+//		allow = .circleci/**
+//		maxFileSize = 2m
+func LoadRepoConfig(root string) (*RepoConfig, error) {
+	cfg := DefaultRepoConfig()
+
+	path := filepath.Join(root, RepoConfigPath)
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	raw := format.New()
+	if err := format.NewDecoder(file).Decode(raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if err := cfg.apply(raw); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// apply copies recognised options from the raw git-config document
+func (c *RepoConfig) apply(raw *format.Config) error {
+	if raw.HasSection("scope") {
+		section := raw.Section("scope")
+		if strategy := strings.ToLower(section.Option("strategy")); strategy != "" {
+			switch strategy {
+			case ScopeStrategyAuto, ScopeStrategyMap, ScopeStrategyPackage, ScopeStrategyTopLevel, ScopeStrategyNone:
+				c.Scope.Strategy = strategy
+			default:
+				return fmt.Errorf("unknown scope.strategy %q", strategy)
+			}
+		}
+		for _, sub := range section.Subsections {
+			prefix := strings.Trim(filepath.ToSlash(sub.Name), "/")
+			name := strings.TrimSpace(sub.Option("name"))
+			if prefix == "" || name == "" {
+				continue
+			}
+			c.Scope.Map[prefix] = name
+		}
+	}
+
+	if raw.HasSection("style") {
+		section := raw.Section("style")
+		if err := parseIntOption(section.Option("history"), "style.history", &c.Style.History); err != nil {
+			return err
+		}
+		if err := parseIntOption(section.Option("examples"), "style.examples", &c.Style.Examples); err != nil {
+			return err
+		}
+	}
+
+	if raw.HasSection("issue") {
+		section := raw.Section("issue")
+		if pattern := section.Option("pattern"); pattern != "" {
+			compiled, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid issue.pattern: %w", err)
+			}
+			c.Issue.Pattern = compiled
+		}
+		if placement := strings.ToLower(section.Option("placement")); placement != "" {
+			switch placement {
+			case "prefix", "trailer", "none":
+				c.Issue.Placement = placement
+			default:
+				return fmt.Errorf("unknown issue.placement %q", placement)
+			}
+		}
+		if trailer := section.Option("trailer"); trailer != "" {
+			c.Issue.Trailer = trailer
+		}
+	}
+
+	if raw.HasSection("trailer") {
+		c.Trailers = append(c.Trailers, raw.Section("trailer").OptionAll("add")...)
+	}
+
+	if raw.HasSection("redact") {
+		section := raw.Section("redact")
+		if enabled := section.Option("enabled"); enabled != "" {
+			value, err := strconv.ParseBool(enabled)
+			if err != nil {
+				return fmt.Errorf("redact.enabled must be true or false, got %q", enabled)
+			}
+			c.Redact.Enabled = value
+		}
+		for _, name := range section.OptionAll("disable") {
+			c.Redact.Disable = append(c.Redact.Disable, strings.Fields(strings.ReplaceAll(name, ",", " "))...)
+		}
+		for _, key := range []string{"allow", "deny"} {
+			for _, pattern := range section.OptionAll(key) {
+				compiled, err := regexp.Compile(pattern)
+				if err != nil {
+					return fmt.Errorf("invalid redact.%s: %w", key, err)
+				}
+				if key == "allow" {
+					c.Redact.Allow = append(c.Redact.Allow, compiled)
+				} else {
+					c.Redact.Deny = append(c.Redact.Deny, compiled)
+				}
+			}
+		}
+	}
+
+	if raw.HasSection("files") {
+		if err := c.Files.apply(raw.Section("files")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply reads the [files] section. Lists may be repeated or comma-separated;
+// setting extensions or deny replaces the default list, and an empty value
+// clears it.
+func (f *FilesConfig) apply(section *format.Section) error {
+	f.Roots = append(f.Roots, section.OptionAll("root")...)
+	if section.HasOption("extensions") {
+		f.Extensions = splitList(section.OptionAll("extensions"))
+	}
+	if section.HasOption("deny") {
+		f.Deny = splitList(section.OptionAll("deny"))
+	}
+	f.AllowHidden = append(f.AllowHidden, splitList(section.OptionAll("allow"))...)
+
+	if hidden := strings.ToLower(section.Option("hidden")); hidden != "" {
+		switch hidden {
+		case "deny", "allow":
+			f.Hidden = hidden
+		default:
+			return fmt.Errorf("unknown files.hidden %q", hidden)
+		}
+	}
+
+	if err := parseSizeOption(section.Option("maxFileSize"), "files.maxFileSize", &f.MaxFileSize); err != nil {
+		return err
+	}
+	if err := parseSizeOption(section.Option("maxTotalSize"), "files.maxTotalSize", &f.MaxTotalSize); err != nil {
+		return err
+	}
+	return parseIntOption(section.Option("maxFiles"), "files.maxFiles", &f.MaxFiles)
+}
+
+// splitList splits repeated, comma-separated values into a list
+func splitList(values []string) []string {
+	list := []string{}
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// parseSizeOption parses a size in bytes with an optional k, m or g suffix, as
+// git does, if it is set
+func parseSizeOption(value, key string, target *int64) error {
+	if value == "" {
+		return nil
+	}
+	number, unit := strings.ToLower(value), int64(1)
+	switch {
+	case strings.HasSuffix(number, "k"):
+		unit = 1024
+	case strings.HasSuffix(number, "m"):
+		unit = 1024 * 1024
+	case strings.HasSuffix(number, "g"):
+		unit = 1024 * 1024 * 1024
+	}
+	if unit > 1 {
+		number = number[:len(number)-1]
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 {
+		return fmt.Errorf("%s must be a positive size such as 512k or 2m, got %q", key, value)
+	}
+	*target = n * unit
+	return nil
+}
+
+// parseIntOption parses a non-negative integer option if it is set
+func parseIntOption(value, key string, target *int) error {
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return fmt.Errorf("%s must be a non-negative integer, got %q", key, value)
+	}
+	*target = n
+	return nil
+}
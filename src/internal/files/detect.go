@@ -0,0 +1,230 @@
+package files
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Encodings of text files that are converted to UTF-8 before they are sent
+const (
+	EncodingUTF16LE     = "UTF-16LE"
+	EncodingUTF16BE     = "UTF-16BE"
+	EncodingWindows1251 = "Windows-1251"
+	EncodingWindows1252 = "Windows-1252"
+)
+
+// sniffSize is how much of a file is inspected to detect its type
+const sniffSize = 8 * 1024
+
+// unknownMIME is what http.DetectContentType returns for content without a
+// known signature
+const unknownMIME = "application/octet-stream"
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// sniff inspects the start of content and returns its MIME type, its text
+// encoding ("" for UTF-8) and whether it is binary. UTF-16 is recognised by its
+// byte order mark or, in content without a known binary signature, by the NUL
+// bytes of ASCII characters; text that is not valid UTF-8 is assumed to be
+// Windows-1251 when high bytes form whole words, as in Cyrillic, and
+// Windows-1252 otherwise.
+func sniff(content []byte) (mime, enc string, binary bool) {
+	head := content
+	if len(head) > sniffSize {
+		head = head[:sniffSize]
+	}
+
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		return "text/plain", "", false
+	case bytes.HasPrefix(head, bomUTF16LE):
+		return "text/plain", EncodingUTF16LE, false
+	case bytes.HasPrefix(head, bomUTF16BE):
+		return "text/plain", EncodingUTF16BE, false
+	}
+
+	// Formats with a known signature, such as images and archives, can have zero
+	// bytes on one parity and must not be taken for UTF-16
+	mime, _, _ = strings.Cut(http.DetectContentType(head), ";")
+	if !strings.HasPrefix(mime, "text/") && mime != unknownMIME {
+		return mime, "", true
+	}
+	if enc := guessUTF16(head); enc != "" {
+		return "text/plain", enc, false
+	}
+	if !strings.HasPrefix(mime, "text/") || bytes.IndexByte(head, 0) >= 0 {
+		return mime, "", true
+	}
+
+	if validUTF8(head) {
+		return mime, "", false
+	}
+	return mime, guessSingleByte(head), false
+}
+
+// guessUTF16 recognises UTF-16 without a byte order mark by the NUL bytes of
+// ASCII characters, which are all on even or all on odd positions
+func guessUTF16(head []byte) string {
+	if len(head) < 4 {
+		return ""
+	}
+	var even, odd int
+	for i, b := range head {
+		if b == 0 {
+			if i%2 == 0 {
+				even++
+			} else {
+				odd++
+			}
+		}
+	}
+	pairs := len(head) / 2
+	switch {
+	case odd > pairs*3/10 && even < pairs/20:
+		return EncodingUTF16LE
+	case even > pairs*3/10 && odd < pairs/20:
+		return EncodingUTF16BE
+	}
+	return ""
+}
+
+// validUTF8 reports whether head is UTF-8, allowing a character cut off at the
+// end and a few invalid bytes in text that is otherwise clearly UTF-8
+func validUTF8(head []byte) bool {
+	var multibyte, invalid int
+	for i := 0; i < len(head) && utf8.FullRune(head[i:]); {
+		r, size := utf8.DecodeRune(head[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			invalid++
+		case size > 1:
+			multibyte++
+		}
+		i += size
+	}
+	return invalid == 0 || multibyte > invalid*10
+}
+
+// guessSingleByte tells Windows-1251 from Windows-1252: Cyrillic words consist
+// of high bytes, while accented Latin letters stand alone among ASCII ones
+func guessSingleByte(head []byte) string {
+	var high, adjacent int
+	for i, b := range head {
+		if b < 0x80 {
+			continue
+		}
+		high++
+		if (i > 0 && head[i-1] >= 0x80) || (i+1 < len(head) && head[i+1] >= 0x80) {
+			adjacent++
+		}
+	}
+	if high > 0 && adjacent*2 > high {
+		return EncodingWindows1251
+	}
+	return EncodingWindows1252
+}
+
+// IsBinary reports whether content is binary, see sniff. UTF-16 and
+// single-byte encoded text is not binary.
+func IsBinary(content []byte) bool {
+	_, _, binary := sniff(content)
+	return binary
+}
+
+// DecodeText returns content converted to UTF-8, or false if it is binary
+func DecodeText(content []byte) (string, bool) {
+	_, enc, binary := sniff(content)
+	if binary {
+		return "", false
+	}
+	text, err := toUTF8(content, enc)
+	if err != nil {
+		return "", false
+	}
+	return text, true
+}
+
+// toUTF8 converts content in the encoding returned by sniff to UTF-8 and drops
+// a byte order mark. Bytes that are invalid in the encoding are replaced with
+// U+FFFD.
+func toUTF8(content []byte, enc string) (string, error) {
+	var decoder *encoding.Decoder
+	switch enc {
+	case EncodingUTF16LE:
+		decoder = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
+	case EncodingUTF16BE:
+		decoder = unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder()
+	case EncodingWindows1251:
+		decoder = charmap.Windows1251.NewDecoder()
+	case EncodingWindows1252:
+		decoder = charmap.Windows1252.NewDecoder()
+	default:
+		return strings.ToValidUTF8(string(bytes.TrimPrefix(content, bomUTF8)), "�"), nil
+	}
+
+	if enc == EncodingUTF16LE || enc == EncodingUTF16BE {
+		content = content[:len(content)/2*2]
+	}
+	text, err := decoder.Bytes(content)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+// languageOverrides names the fence language of files chroma gets wrong
+var languageOverrides = map[string]string{
+	"go.mod":  "go",
+	"go.work": "go",
+	"go.sum":  "text",
+}
+
+// shebang matches the interpreter of a script, e.g. "python3" in
+// "#!/usr/bin/env python3"
+var shebang = regexp.MustCompile(`^#!\s*(?:/\S*/)?(?:env\s+(?:-\S+\s+)*)?([A-Za-z]+)`)
+
+// detectLanguage returns the language of a code fence for a file, from its name
+// or, when the name does not tell, from a shebang line or the content
+func detectLanguage(path, content string) string {
+	base := filepath.Base(path)
+	if lang, ok := languageOverrides[base]; ok {
+		return lang
+	}
+
+	lexer := lexers.Match(base)
+	if lexer == nil {
+		if match := shebang.FindStringSubmatch(content); match != nil {
+			lexer = lexers.Get(match[1])
+		}
+	}
+	if lexer == nil {
+		lexer = lexers.Analyse(content)
+	}
+	return fenceName(lexer)
+}
+
+// fenceName returns the shortest common name of a lexer, as used after ```
+func fenceName(lexer chroma.Lexer) string {
+	if lexer == nil {
+		return "text"
+	}
+	config := lexer.Config()
+	if len(config.Aliases) > 0 {
+		return config.Aliases[0]
+	}
+	return strings.ToLower(config.Name)
+}
@@ -0,0 +1,300 @@
+package files
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// vendorDirs are dependency directories that are never expanded
+var vendorDirs = map[string]bool{
+	"vendor":       true,
+	"node_modules": true,
+}
+
+// Exclusion is a file or directory left out while expanding, with the reason
+type Exclusion struct {
+	Path   string
+	Reason string
+}
+
+// Expansion is the result of expanding --file arguments
+type Expansion struct {
+	Files    []string
+	Excluded []Exclusion
+	Expanded bool // at least one argument was a directory or a pattern
+}
+
+// Expand turns file arguments into a list of files. Besides plain files, an
+// argument may be a directory (read recursively), a Go-style "dir/..." pattern or
+// a glob such as "src/**/*.go", where "**" matches any number of directories.
+//
+// While expanding, files ignored by git, hidden files (unless the policy allows
+// all of them), vendor directories, binaries, files the policy refuses and files
+// that are too large are skipped and reported in Excluded. Plain file arguments
+// are always kept. The result is capped at the policy's file count and total
+// size limits.
+func (r *Reader) Expand(args []string) (*Expansion, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine current directory: %w", err)
+	}
+
+	e := &expander{
+		reader:    r,
+		result:    &Expansion{},
+		seen:      map[string]bool{},
+		gitIgnore: newGitIgnore(cwd),
+	}
+
+	for _, arg := range args {
+		if strings.TrimSpace(arg) == "" {
+			return nil, fmt.Errorf("empty file path")
+		}
+
+		switch {
+		case arg == "..." || strings.HasSuffix(arg, "/..."):
+			dir := strings.TrimSuffix(strings.TrimSuffix(arg, "..."), "/")
+			if dir == "" {
+				dir = "."
+			}
+			err = e.walkDir(dir, nil)
+		case strings.ContainsAny(arg, "*?["):
+			err = e.walkGlob(arg)
+		default:
+			path, selector, splitErr := SplitSelector(arg)
+			if splitErr != nil {
+				return nil, splitErr
+			}
+
+			var info os.FileInfo
+			switch info, err = os.Stat(path); {
+			case err == nil && info.IsDir() && selector != nil:
+				err = fmt.Errorf("%s is a directory: line ranges and symbols select part of a file", arg)
+			case err == nil && info.IsDir():
+				err = e.walkDir(path, nil)
+			case selector != nil:
+				// Excerpts are kept as written and cut out by ReadFiles
+				e.add(filepath.Clean(path)+selector.String(), 0)
+				err = nil
+			default:
+				// Plain files, including missing ones, are validated by ValidateFiles
+				e.add(filepath.Clean(arg), 0)
+				err = nil
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return e.result, nil
+}
+
+// expander holds the state of one Expand call
+type expander struct {
+	reader    *Reader
+	result    *Expansion
+	seen      map[string]bool
+	gitIgnore *gitIgnore
+	totalSize int64
+}
+
+// walkGlob expands a glob by walking the directory before its first wildcard
+func (e *expander) walkGlob(pattern string) error {
+	segments := strings.Split(path.Clean(filepath.ToSlash(pattern)), "/")
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+	}
+
+	base := 0
+	for base < len(segments) && !strings.ContainsAny(segments[base], "*?[") {
+		base++
+	}
+	dir := filepath.FromSlash(strings.Join(segments[:base], "/"))
+	if dir == "" {
+		dir = "."
+	}
+
+	return e.walkDir(dir, segments[base:])
+}
+
+// walkDir adds the files below dir. With a non-empty pattern, only files whose
+// path relative to dir matches it are considered.
+func (e *expander) walkDir(dir string, pattern []string) error {
+	if _, err := e.reader.policy.within(dir); err != nil {
+		return err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("file %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("path %s is not a directory", dir)
+	}
+	e.result.Expanded = true
+
+	recursive := len(pattern) == 0
+	for _, segment := range pattern {
+		recursive = recursive || segment == "**"
+	}
+
+	return filepath.WalkDir(dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			e.exclude(p, "unreadable")
+			if entry != nil && entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if p == dir {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(dir, p)
+		rel = filepath.ToSlash(rel)
+		name := entry.Name()
+
+		if entry.IsDir() {
+			switch {
+			case name == ".git":
+				return filepath.SkipDir
+			case !recursive && strings.Count(rel, "/")+1 >= len(pattern):
+				return filepath.SkipDir
+			case strings.HasPrefix(name, ".") && !e.reader.policy.hiddenDirAllowed(p):
+				e.exclude(p+"/", "hidden directory")
+				return filepath.SkipDir
+			case vendorDirs[name]:
+				e.exclude(p+"/", "vendor directory")
+				return filepath.SkipDir
+			case e.ignored(p, true):
+				e.exclude(p+"/", "ignored by .gitignore")
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if len(pattern) > 0 && !matchSegments(pattern, strings.Split(rel, "/")) {
+			return nil
+		}
+		e.addWalked(p, entry)
+		return nil
+	})
+}
+
+// addWalked adds a file found while walking unless it should be skipped
+func (e *expander) addWalked(p string, entry fs.DirEntry) {
+	if entry.Type()&fs.ModeSymlink != 0 {
+		e.exclude(p, "symbolic link")
+		return
+	}
+	if !entry.Type().IsRegular() {
+		return
+	}
+	if e.ignored(p, false) {
+		e.exclude(p, "ignored by .gitignore")
+		return
+	}
+	if err := e.reader.policy.Check(p); err != nil {
+		reason := err.Error()
+		if policyErr, ok := err.(*PolicyError); ok {
+			reason = policyErr.Reason
+		}
+		e.exclude(p, reason)
+		return
+	}
+
+	info, err := entry.Info()
+	if err != nil {
+		e.exclude(p, "unreadable")
+		return
+	}
+	if info.Size() > e.reader.maxFileSize {
+		e.exclude(p, fmt.Sprintf("too large (%s)", formatSize(info.Size())))
+		return
+	}
+	if binary, err := e.sniffBinary(p); err != nil || binary {
+		e.exclude(p, "binary")
+		return
+	}
+
+	e.add(p, info.Size())
+}
+
+// add records a file, enforcing the file count and total size limits
+func (e *expander) add(p string, size int64) {
+	if e.seen[p] {
+		return
+	}
+	e.seen[p] = true
+
+	switch {
+	case len(e.result.Files) >= e.reader.policy.maxFiles:
+		e.exclude(p, fmt.Sprintf("file limit reached (%d)", e.reader.policy.maxFiles))
+	case e.totalSize+size > e.reader.maxTotalSize:
+		e.exclude(p, fmt.Sprintf("total size limit reached (%s)", formatSize(e.reader.maxTotalSize)))
+	default:
+		e.result.Files = append(e.result.Files, p)
+		e.totalSize += size
+	}
+}
+
+func (e *expander) exclude(p, reason string) {
+	e.result.Excluded = append(e.result.Excluded, Exclusion{Path: p, Reason: reason})
+}
+
+func (e *expander) ignored(p string, isDir bool) bool {
+	if e.gitIgnore == nil {
+		return false
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return false
+	}
+	return e.gitIgnore.Ignored(abs, isDir)
+}
+
+// sniffBinary reads the start of a file to tell whether it is binary
+func (e *expander) sniffBinary(p string) (bool, error) {
+	file, err := os.Open(p)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	return IsBinary(head[:n]), nil
+}
+
+// matchSegments matches slash-separated path segments against a glob, where a
+// "**" segment matches zero or more directories
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
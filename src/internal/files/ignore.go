@@ -0,0 +1,100 @@
+package files
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// gitIgnore matches paths against the ignore rules of the repository containing
+// them. .gitignore files are read lazily as directories are visited, so that
+// walking a subdirectory does not read the whole tree.
+type gitIgnore struct {
+	root     string // absolute worktree root
+	patterns []gitignore.Pattern
+	loaded   map[string]bool // directories whose .gitignore has been read
+}
+
+// newGitIgnore returns the ignore rules for the repository containing dir, or nil
+// when dir is not inside a git worktree
+func newGitIgnore(dir string) *gitIgnore {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil
+	}
+
+	root, err := filepath.Abs(worktree.Filesystem.Root())
+	if err != nil {
+		return nil
+	}
+	g := &gitIgnore{root: root, loaded: map[string]bool{}}
+
+	// Lowest priority first: system and global excludes, then .git/info/exclude
+	rootFS := osfs.New("/")
+	if ps, err := gitignore.LoadSystemPatterns(rootFS); err == nil {
+		g.patterns = append(g.patterns, ps...)
+	}
+	if ps, err := gitignore.LoadGlobalPatterns(rootFS); err == nil {
+		g.patterns = append(g.patterns, ps...)
+	}
+	g.readFile(filepath.Join(root, ".git", "info", "exclude"), nil)
+
+	return g
+}
+
+// Ignored reports whether path (absolute) is ignored. The .gitignore files of the
+// directories between the root and path are loaded first.
+func (g *gitIgnore) Ignored(path string, isDir bool) bool {
+	segments := g.segments(path)
+	if segments == nil {
+		return false
+	}
+
+	for i := 0; i < len(segments); i++ {
+		dir := segments[:i]
+		key := strings.Join(dir, "/")
+		if !g.loaded[key] {
+			g.loaded[key] = true
+			g.readFile(filepath.Join(append([]string{g.root}, dir...)...)+string(filepath.Separator)+".gitignore", dir)
+		}
+	}
+
+	return gitignore.NewMatcher(g.patterns).Match(segments, isDir)
+}
+
+// segments splits path into its components relative to the root; nil when path
+// is the root itself or outside of it
+func (g *gitIgnore) segments(path string) []string {
+	rel, err := filepath.Rel(g.root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	return strings.Split(filepath.ToSlash(rel), "/")
+}
+
+// readFile adds the patterns of one ignore file, scoped to domain
+func (g *gitIgnore) readFile(path string, domain []string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		g.patterns = append(g.patterns, gitignore.ParsePattern(line, append([]string(nil), domain...)))
+	}
+}
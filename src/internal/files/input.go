@@ -0,0 +1,92 @@
+package files
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxInputSize limits piped input and command output kept as context
+const maxInputSize = 1024 * 1024 // 1MB
+
+// Sources of context that is not read from a file
+const (
+	SourceStdin   = "stdin"
+	SourceCommand = "command"
+	SourceRepo    = "repo"
+)
+
+// ReadInput reads piped input or command output as context named name. Only the
+// last maxInputSize bytes are kept: logs and test output report failures at the
+// end. The returned Size is the size of the whole input.
+func (r *Reader) ReadInput(in io.Reader, name, source string) (*FileContent, error) {
+	var tail tailBuffer
+	size, err := io.Copy(&tail, in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	content := tail.Bytes()
+	mime, encoding, binary := sniff(content)
+	if binary {
+		return nil, fmt.Errorf("%s looks like binary data (%s)", name, mime)
+	}
+	text, err := toUTF8(content, encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: invalid %s text: %w", name, encoding, err)
+	}
+
+	return &FileContent{
+		Path:      name,
+		Content:   strings.TrimRight(text, "\r\n"),
+		Size:      size,
+		Source:    source,
+		Truncated: size > int64(len(content)),
+		Encoding:  encoding,
+	}, nil
+}
+
+// tailBuffer keeps the last maxInputSize bytes written to it
+type tailBuffer struct {
+	data []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	if len(b.data) > 2*maxInputSize {
+		b.data = append(b.data[:0], b.data[len(b.data)-maxInputSize:]...)
+	}
+	return len(p), nil
+}
+
+// Bytes returns the kept bytes, starting at a line boundary when input was cut
+func (b *tailBuffer) Bytes() []byte {
+	if len(b.data) <= maxInputSize {
+		return b.data
+	}
+	return cutToLine(b.data[len(b.data)-maxInputSize:])
+}
+
+// cutTail keeps the end of content that fits into limit tokens, starting at a
+// line boundary
+func cutTail(content string, limit int, estimate func(string) int) string {
+	for tokens := estimate(content); tokens > limit && content != ""; tokens = estimate(content) {
+		// Keep a bit less than the share that fits, then check again
+		keep := len(content) * limit / tokens
+		keep -= keep/10 + 1
+		if keep <= 0 {
+			return ""
+		}
+		drop := len(content) - keep
+		content = string(cutToLine([]byte(content[drop:])))
+	}
+	return content
+}
+
+// cutToLine drops the partial line at the start of data, if any
+func cutToLine(data []byte) []byte {
+	if i := strings.IndexByte(string(data), '\n'); i >= 0 && i+1 < len(data) {
+		return data[i+1:]
+	}
+	return data
+}
@@ -0,0 +1,80 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// MIMEPDF is the MIME type of PDF documents, whose text is extracted
+const MIMEPDF = "application/pdf"
+
+// imageTypes are the image formats sent to models that accept images
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ImageTokens is roughly what an image costs in the context window of a
+// vision model
+const ImageTokens = 1000
+
+// IsImage reports whether mime is an image format that can be sent to a model
+func IsImage(mime string) bool {
+	return imageTypes[mime]
+}
+
+// extractPDF returns the text of a PDF document, page by page, and the number
+// of pages. Text is rebuilt from the position of every glyph: a gap starts a new
+// word and a change of baseline a new line, since many PDFs contain no spaces.
+func extractPDF(content []byte) (text string, pages int, err error) {
+	// The parser panics on some malformed documents
+	defer func() {
+		if r := recover(); r != nil {
+			text, pages, err = "", 0, fmt.Errorf("invalid PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid PDF: %w", err)
+	}
+
+	var builder strings.Builder
+	pages = reader.NumPage()
+	for i := 1; i <= pages; i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		if i > 1 {
+			builder.WriteString(fmt.Sprintf("\n\n--- Страница %d ---\n\n", i))
+		}
+
+		var last *pdf.Text
+		glyphs := page.Content().Text
+		for j := range glyphs {
+			glyph := &glyphs[j]
+			// Zero-width glyphs are line breaks mapped through the font
+			if glyph.W == 0 || glyph.S == "�" {
+				continue
+			}
+			if last != nil {
+				switch {
+				case math.Abs(glyph.Y-last.Y) > last.FontSize/2:
+					builder.WriteString("\n")
+				case glyph.X-(last.X+last.W) > last.FontSize*0.15:
+					builder.WriteString(" ")
+				}
+			}
+			builder.WriteString(glyph.S)
+			last = glyph
+		}
+	}
+	return strings.TrimSpace(builder.String()), pages, nil
+}
@@ -0,0 +1,138 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// outlineFallbackLines is how many leading lines are kept for files without
+// recognizable declarations
+const outlineFallbackLines = 20
+
+// goPrinter prints declarations the way gofmt does
+var goPrinter = &printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+
+// declarationLine matches lines that declare something in common languages:
+// functions, classes, types and Markdown headings
+var declarationLine = regexp.MustCompile(`^\s*(` +
+	`(export\s+)?(default\s+)?(async\s+)?(function|class|interface|type|enum)\b` +
+	`|(export\s+)?(const|let)\s+\w+\s*=\s*(async\s*)?(\([^)]*\)|\w+)\s*=>` +
+	`|(async\s+)?def\s+\w+|class\s+\w+` +
+	`|(pub(\([^)]*\))?\s+)?(async\s+)?(fn|struct|enum|trait|impl|mod)\b` +
+	`|((public|private|protected|internal|static|final|abstract|override|suspend)\s+)+[\w<>\[\],\s]*\w+\s*\(` +
+	`|(fun|func|module|namespace|package|object|record)\s+\w+` +
+	`|#{1,6}\s+\S` +
+	`)`)
+
+// Outline reduces a file to its declarations so that a large file can still be
+// described when it does not fit whole. Go files are parsed and keep their
+// signatures and types; other files keep the lines that look like declarations.
+// Every kept declaration is annotated with its line numbers.
+func Outline(path, content string) string {
+	if strings.HasSuffix(path, ".go") {
+		if outline, err := outlineGo(content); err == nil {
+			return outline
+		}
+	}
+	return outlineText(content)
+}
+
+// outlineGo keeps the package clause, imports, type, const and var declarations
+// and function signatures of a Go file, with doc comments shortened to one line
+func outlineGo(content string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	builder.WriteString("package " + file.Name.Name + "\n")
+
+	for _, decl := range file.Decls {
+		start := fset.Position(decl.Pos()).Line
+		end := fset.Position(decl.End()).Line
+
+		var doc *ast.CommentGroup
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			doc = d.Doc
+			// Print the signature only
+			d.Doc, d.Body = nil, nil
+		case *ast.GenDecl:
+			doc = d.Doc
+			d.Doc = nil
+			if d.Tok == token.IMPORT {
+				start = 0
+			}
+		}
+
+		var printed bytes.Buffer
+		if err := goPrinter.Fprint(&printed, fset, decl); err != nil {
+			return "", err
+		}
+
+		builder.WriteString("\n")
+		if doc != nil {
+			builder.WriteString("// " + firstDocLine(doc.Text()) + "\n")
+		}
+		builder.WriteString(strings.TrimRight(stripComments(printed.String()), "\n"))
+		if start > 0 {
+			builder.WriteString(fmt.Sprintf(" // lines %d-%d", start, end))
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String(), nil
+}
+
+// outlineText keeps declaration-like lines prefixed with their line numbers, or
+// the first lines when none are found
+func outlineText(content string) string {
+	lines := strings.Split(content, "\n")
+
+	var builder strings.Builder
+	for i, line := range lines {
+		if declarationLine.MatchString(line) {
+			builder.WriteString(fmt.Sprintf("%d: %s\n", i+1, strings.TrimRight(line, " \t\r{")))
+		}
+	}
+	if builder.Len() > 0 {
+		return builder.String()
+	}
+
+	if len(lines) > outlineFallbackLines {
+		lines = lines[:outlineFallbackLines]
+	}
+	for i, line := range lines {
+		builder.WriteString(fmt.Sprintf("%d: %s\n", i+1, line))
+	}
+	builder.WriteString("...\n")
+	return builder.String()
+}
+
+// firstDocLine returns the first sentence line of a doc comment
+func firstDocLine(doc string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(doc), "\n")
+	return line
+}
+
+// stripComments drops comment-only lines the printer kept inside declarations,
+// such as comments on struct fields that sit on their own line
+func stripComments(code string) string {
+	lines := strings.Split(code, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "//") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
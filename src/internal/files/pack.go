@@ -0,0 +1,181 @@
+package files
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"zero-workflow/src/internal/identifier"
+)
+
+// fileOverheadTokens approximates the header and code fence around each file
+const fileOverheadTokens = 30
+
+// stopWords are left out when matching a question against files
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "this": true, "that": true, "with": true,
+	"what": true, "how": true, "why": true, "does": true, "are": true, "can": true,
+	"from": true, "into": true, "where": true, "which": true, "code": true, "file": true,
+	"files": true, "explain": true, "about": true, "should": true, "there": true,
+	"как": true, "что": true, "это": true, "где": true, "для": true, "почему": true,
+	"код": true, "файл": true, "файлы": true, "объясни": true,
+}
+
+// Ways a file is trimmed to fit the token budget
+const (
+	TrimOutline = "outline" // declarations only, see Outline
+	TrimTail    = "tail"    // the end of piped input or command output
+	TrimOmitted = "omitted"
+)
+
+// Trim describes a file that did not fit whole into the token budget
+type Trim struct {
+	Path   string
+	How    string // TrimOutline, TrimTail or TrimOmitted
+	Tokens int    // estimated tokens of the whole file
+	Sent   int    // tokens sent instead; 0 when the file was omitted
+}
+
+// Packing is the result of fitting files into a token budget
+type Packing struct {
+	Files   []FileContent // files to send, in the original order
+	Trimmed []Trim        // files sent as outlines, cut or omitted, by relevance
+	Tokens  int           // estimated tokens of Files
+	Budget  int
+}
+
+// Pack fits files into a token budget. Piped input and command output come
+// first and are cut to their end when they are too large. The remaining files
+// are ranked by how relevant they look to the question. Every file first gets
+// its outline if it fits, then the most relevant files are upgraded to their
+// whole content while the budget allows. Files whose outline does not fit
+// either are omitted.
+func (r *Reader) Pack(files []FileContent, question string, budget int, estimate func(string) int) *Packing {
+	type candidate struct {
+		file      FileContent
+		score     float64
+		tokens    int
+		outline   string
+		outlineTk int
+		mode      int // 0 omitted, 1 outline, 2 whole
+		cut       int // tokens of the input before it was cut
+	}
+
+	terms := queryTerms(question)
+	candidates := make([]*candidate, len(files))
+	for i, file := range files {
+		c := &candidate{file: file, score: relevance(file, terms)}
+		c.tokens = estimate(file.Content) + fileOverheadTokens
+		if file.Image != nil {
+			c.tokens += ImageTokens
+		}
+		switch {
+		case file.Source != "":
+			c.score = math.Inf(1)
+			c.outline, c.outlineTk = file.Content, c.tokens
+		case file.Placeholder || file.Start > 0:
+			// Placeholders are as small as their outline would be, and excerpts
+			// were chosen on purpose: both are sent as they are or not at all
+			c.outline, c.outlineTk = file.Content, c.tokens
+		default:
+			c.outline = Outline(file.Path, file.Content)
+			c.outlineTk = estimate(c.outline) + fileOverheadTokens
+		}
+		if c.outlineTk > c.tokens {
+			c.outline, c.outlineTk = file.Content, c.tokens
+		}
+		candidates[i] = c
+	}
+
+	ranked := append([]*candidate(nil), candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	used := 0
+	for _, c := range ranked {
+		if c.file.Source != "" && used+c.tokens > budget && budget-used > fileOverheadTokens {
+			c.cut = c.tokens
+			c.file.Content = cutTail(c.file.Content, budget-used-fileOverheadTokens, estimate)
+			c.file.Truncated = true
+			c.tokens = estimate(c.file.Content) + fileOverheadTokens
+			c.outline, c.outlineTk = c.file.Content, c.tokens
+		}
+		if used+c.outlineTk <= budget {
+			c.mode = 1
+			used += c.outlineTk
+		}
+	}
+	for _, c := range ranked {
+		if c.mode == 1 && used-c.outlineTk+c.tokens <= budget {
+			c.mode = 2
+			used += c.tokens - c.outlineTk
+		}
+	}
+
+	packing := &Packing{Tokens: used, Budget: budget}
+	for _, c := range candidates {
+		switch {
+		case c.mode == 2 || (c.mode == 1 && c.outline == c.file.Content):
+			packing.Files = append(packing.Files, c.file)
+		case c.mode == 1:
+			outlined := c.file
+			outlined.Content = c.outline
+			outlined.Outline = true
+			packing.Files = append(packing.Files, outlined)
+		}
+	}
+	for _, c := range ranked {
+		switch {
+		case c.mode == 0:
+			packing.Trimmed = append(packing.Trimmed, Trim{Path: c.file.Path, How: TrimOmitted, Tokens: max(c.tokens, c.cut)})
+		case c.cut > 0:
+			packing.Trimmed = append(packing.Trimmed, Trim{Path: c.file.Path, How: TrimTail, Tokens: c.cut, Sent: c.tokens})
+		case c.mode == 1 && c.outline != c.file.Content:
+			packing.Trimmed = append(packing.Trimmed, Trim{Path: c.file.Path, How: TrimOutline, Tokens: c.tokens, Sent: c.outlineTk})
+		}
+	}
+
+	return packing
+}
+
+// queryTerms splits a question into lowercase search terms. Identifiers are also
+// split at camelCase and snake_case boundaries, so "ReadFiles" matches "read_files".
+func queryTerms(question string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	add := func(term string) {
+		term = strings.ToLower(term)
+		if len([]rune(term)) < 3 || stopWords[term] || seen[term] {
+			return
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+
+	words := strings.FieldsFunc(question, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, word := range words {
+		add(word)
+		for _, part := range identifier.Split(word) {
+			add(part)
+		}
+	}
+	return terms
+}
+
+// relevance scores a file against the question terms. Matches in the path weigh
+// more than matches in the content, and repeated content matches add less and less.
+func relevance(file FileContent, terms []string) float64 {
+	path := strings.ToLower(file.Path)
+	content := strings.ToLower(file.Content)
+
+	var score float64
+	for _, term := range terms {
+		score += 3 * float64(strings.Count(path, term))
+		score += math.Log1p(float64(strings.Count(content, term)))
+	}
+	return score
+}
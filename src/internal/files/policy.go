@@ -0,0 +1,271 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"zero-workflow/src/internal/config"
+)
+
+// Policy decides which files may be read. It is configured in the [files]
+// section of .zw/config.
+type Policy struct {
+	roots        []string // absolute directories files may be read from
+	extensions   map[string]bool
+	deny         map[string]bool
+	hidden       bool       // all hidden paths are allowed
+	allowHidden  [][]string // slash-separated patterns of allowed hidden paths
+	maxFileSize  int64
+	maxTotalSize int64
+	maxFiles     int
+}
+
+// PolicyError explains why a file was refused and how to allow it
+type PolicyError struct {
+	Path   string
+	Reason string
+	Hint   string
+}
+
+func (e *PolicyError) Error() string {
+	message := fmt.Sprintf("%s: %s", e.Path, e.Reason)
+	if e.Hint != "" {
+		message += "; " + e.Hint
+	}
+	return message
+}
+
+// LoadPolicy returns the policy for the current directory: files may be read
+// from the repository containing it, or from the directory itself outside a
+// repository, and .zw/config at the repository root adjusts the rules.
+func LoadPolicy() (*Policy, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine current directory: %w", err)
+	}
+
+	root := cwd
+	repoConfig := config.DefaultRepoConfig()
+	if repo, err := git.PlainOpenWithOptions(cwd, &git.PlainOpenOptions{DetectDotGit: true}); err == nil {
+		if worktree, err := repo.Worktree(); err == nil {
+			root = worktree.Filesystem.Root()
+			if repoConfig, err = config.LoadRepoConfig(root); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return NewPolicy(repoConfig.Files, root)
+}
+
+// NewPolicy creates a policy that allows reading below root and the configured
+// roots, which are relative to root
+func NewPolicy(cfg config.FilesConfig, root string) (*Policy, error) {
+	p := &Policy{
+		extensions:   nameSet(cfg.Extensions),
+		deny:         nameSet(cfg.Deny),
+		hidden:       cfg.Hidden == "allow",
+		maxFileSize:  cfg.MaxFileSize,
+		maxTotalSize: cfg.MaxTotalSize,
+		maxFiles:     cfg.MaxFiles,
+	}
+
+	for _, dir := range append([]string{"."}, cfg.Roots...) {
+		if strings.HasPrefix(dir, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("cannot resolve files.root %s: %w", dir, err)
+			}
+			dir = filepath.Join(home, dir[2:])
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		p.roots = append(p.roots, resolve(dir))
+	}
+
+	for _, pattern := range cfg.AllowHidden {
+		segments := strings.Split(path.Clean(filepath.ToSlash(pattern)), "/")
+		for _, segment := range segments {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf("invalid files.allow pattern %s: %w", pattern, err)
+			}
+		}
+		p.allowHidden = append(p.allowHidden, segments)
+	}
+	return p, nil
+}
+
+// nameSet normalizes a list of extensions (".go") and file names ("Makefile").
+// An empty list gives nil.
+func nameSet(names []string) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+	set := map[string]bool{}
+	for _, name := range names {
+		set[strings.ToLower(name)] = true
+	}
+	return set
+}
+
+// resolve returns the absolute path of p with symbolic links evaluated, as far
+// as it exists
+func resolve(p string) string {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return filepath.Clean(p)
+	}
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		return real
+	}
+	// Resolve the existing parent, so that missing files are still compared
+	// against resolved roots
+	parent, name := filepath.Split(abs)
+	if parent = filepath.Clean(parent); parent != abs {
+		return filepath.Join(resolve(parent), name)
+	}
+	return abs
+}
+
+// Check returns a *PolicyError if p may not be read
+func (p *Policy) Check(name string) error {
+	rel, err := p.within(name)
+	if err != nil {
+		return err
+	}
+
+	if err := p.checkHidden(name, rel); err != nil {
+		return err
+	}
+
+	base := strings.ToLower(path.Base(rel))
+	ext := strings.ToLower(path.Ext(rel))
+	if p.deny[base] || (ext != "" && p.deny[ext]) {
+		kind := "files named " + path.Base(rel)
+		if !p.deny[base] {
+			kind = ext + " files"
+		}
+		return &PolicyError{
+			Path:   name,
+			Reason: kind + " are not allowed",
+			Hint:   fmt.Sprintf("change the deny list in the [files] section of %s", config.RepoConfigPath),
+		}
+	}
+	if p.extensions != nil && !p.extensions[base] && !(ext != "" && p.extensions[ext]) {
+		return &PolicyError{
+			Path:   name,
+			Reason: "not in the allowed extensions",
+			Hint:   fmt.Sprintf("add it to extensions in the [files] section of %s", config.RepoConfigPath),
+		}
+	}
+	return nil
+}
+
+// within returns the slash-separated path of name relative to the first root
+// containing it, after resolving symbolic links
+func (p *Policy) within(name string) (string, error) {
+	real := resolve(name)
+	for _, root := range p.roots {
+		rel, err := filepath.Rel(root, real)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel), nil
+		}
+	}
+
+	dir := real
+	if info, err := os.Stat(real); err != nil || !info.IsDir() {
+		dir = filepath.Dir(real)
+	}
+	return "", &PolicyError{
+		Path:   name,
+		Reason: "outside the repository",
+		Hint:   fmt.Sprintf("allow the directory with \"root = %s\" in the [files] section of %s", dir, config.RepoConfigPath),
+	}
+}
+
+// checkHidden refuses paths inside .git and, unless all hidden paths are
+// allowed, paths with a hidden component that no allow pattern matches.
+// Patterns without a slash match a single component such as ".env"; others
+// match from the root, such as ".github/**".
+func (p *Policy) checkHidden(name, rel string) error {
+	segments := strings.Split(rel, "/")
+	for i, segment := range segments {
+		if segment == ".git" {
+			return &PolicyError{Path: name, Reason: "inside the .git directory"}
+		}
+		if p.hidden || !strings.HasPrefix(segment, ".") || p.hiddenAllowed(segments, i) {
+			continue
+		}
+
+		hidden := strings.Join(segments[:i+1], "/")
+		pattern := hidden
+		if i < len(segments)-1 {
+			pattern += "/**"
+		}
+		return &PolicyError{
+			Path:   name,
+			Reason: "hidden path " + hidden + " is not allowed",
+			Hint:   fmt.Sprintf("allow it with \"allow = %s\" in the [files] section of %s", pattern, config.RepoConfigPath),
+		}
+	}
+	return nil
+}
+
+// hiddenDirAllowed reports whether a hidden directory found while walking may
+// contain files that checkHidden allows, e.g. ".github" for ".github/**"
+func (p *Policy) hiddenDirAllowed(name string) bool {
+	rel, err := p.within(name)
+	if err != nil {
+		return false
+	}
+	segments := strings.Split(rel, "/")
+	for i, segment := range segments {
+		if segment == ".git" {
+			return false
+		}
+		if p.hidden || !strings.HasPrefix(segment, ".") || p.hiddenAllowed(segments, i) || p.hiddenPrefix(segments[:i+1]) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// hiddenPrefix reports whether an allow pattern of several segments can match
+// below the directory given by segments
+func (p *Policy) hiddenPrefix(segments []string) bool {
+	for _, pattern := range p.allowHidden {
+		for i := 0; len(pattern) > 1 && i < len(pattern); i++ {
+			if pattern[i] == "**" {
+				return true
+			}
+			if i == len(segments) {
+				return true
+			}
+			if ok, _ := path.Match(pattern[i], segments[i]); !ok {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// hiddenAllowed reports whether an allow pattern covers segments[i]
+func (p *Policy) hiddenAllowed(segments []string, i int) bool {
+	for _, pattern := range p.allowHidden {
+		if len(pattern) == 1 {
+			if ok, _ := path.Match(pattern[0], segments[i]); ok {
+				return true
+			}
+			continue
+		}
+		if matchSegments(pattern, segments[:i+1]) || matchSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
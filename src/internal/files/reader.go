@@ -6,11 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-)
 
-const (
-	maxFileSize = 1024 * 1024 // 1MB limit per file
-	maxTotalSize = 5 * 1024 * 1024 // 5MB total limit
+	"zero-workflow/src/internal/config"
 )
 
 // FileContent represents a file with its content
@@ -18,19 +15,51 @@ type FileContent struct {
 	Path    string
 	Content string
 	Size    int64
+	Outline bool // Content is an outline of the file, see Outline
+
+	// Content is a placeholder describing an image or a binary file rather
+	// than its text, see readFile
+	Placeholder bool
+
+	// Set when only part of the file was selected, see SplitSelector
+	Start  int
+	End    int
+	Symbol string
+
+	// Set for piped input and command output, see ReadInput
+	Source    string
+	ExitCode  int
+	Truncated bool // only the end of the input is kept
+
+	Encoding string // encoding the content was converted from, "" for UTF-8
+
+	// Set for images and PDFs, see readFile
+	MIME  string
+	Image []byte // image data, nil when the image is not sent
+	Pages int    // pages of a PDF whose text is the content
 }
 
 // Reader handles reading and processing files
 type Reader struct {
+	policy       *Policy
 	maxFileSize  int64
 	maxTotalSize int64
 }
 
-// NewReader creates a new file reader
+// NewReader creates a new file reader with the default policy: files below the
+// current directory, without the settings of .zw/config
 func NewReader() *Reader {
+	cwd, _ := os.Getwd()
+	policy, _ := NewPolicy(config.DefaultRepoConfig().Files, cwd)
+	return NewReaderWithPolicy(policy)
+}
+
+// NewReaderWithPolicy creates a file reader that reads only what policy allows
+func NewReaderWithPolicy(policy *Policy) *Reader {
 	return &Reader{
-		maxFileSize:  maxFileSize,
-		maxTotalSize: maxTotalSize,
+		policy:       policy,
+		maxFileSize:  policy.maxFileSize,
+		maxTotalSize: policy.maxTotalSize,
 	}
 }
 
@@ -39,7 +68,12 @@ func (r *Reader) ReadFiles(filePaths []string) ([]FileContent, error) {
 	var files []FileContent
 	var totalSize int64
 
-	for _, path := range filePaths {
+	for _, arg := range filePaths {
+		path, selector, err := SplitSelector(arg)
+		if err != nil {
+			return nil, err
+		}
+
 		// Clean and validate path
 		cleanPath := filepath.Clean(path)
 		
@@ -56,27 +90,34 @@ func (r *Reader) ReadFiles(filePaths []string) ([]FileContent, error) {
 
 		// Check file size
 		if info.Size() > r.maxFileSize {
-			return nil, fmt.Errorf("file %s is too large (%.2f MB), max allowed: %.2f MB", 
-				cleanPath, float64(info.Size())/1024/1024, float64(r.maxFileSize)/1024/1024)
+			return nil, &PolicyError{
+				Path:   cleanPath,
+				Reason: fmt.Sprintf("file is too large (%s), max allowed: %s", formatSize(info.Size()), formatSize(r.maxFileSize)),
+				Hint:   fmt.Sprintf("raise maxFileSize in the [files] section of %s", config.RepoConfigPath),
+			}
 		}
 
 		// Check total size limit
 		if totalSize + info.Size() > r.maxTotalSize {
-			return nil, fmt.Errorf("total files size exceeds limit (%.2f MB)", 
-				float64(r.maxTotalSize)/1024/1024)
+			return nil, &PolicyError{
+				Path:   cleanPath,
+				Reason: fmt.Sprintf("total files size exceeds limit (%s)", formatSize(r.maxTotalSize)),
+				Hint:   fmt.Sprintf("raise maxTotalSize in the [files] section of %s", config.RepoConfigPath),
+			}
 		}
 
 		// Read file content
-		content, err := r.readFile(cleanPath)
+		file, err := r.readFile(cleanPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read %s: %w", cleanPath, err)
 		}
-
-		files = append(files, FileContent{
-			Path:    cleanPath,
-			Content: content,
-			Size:    info.Size(),
-		})
+		file.Size = info.Size()
+		if selector != nil {
+			if err := excerpt(&file, selector); err != nil {
+				return nil, err
+			}
+		}
+		files = append(files, file)
 
 		totalSize += info.Size()
 	}
@@ -84,44 +125,65 @@ func (r *Reader) ReadFiles(filePaths []string) ([]FileContent, error) {
 	return files, nil
 }
 
-// readFile reads a single file and returns its content
-func (r *Reader) readFile(path string) (string, error) {
-	file, err := os.Open(path)
+// readFile reads a single file and returns its content in UTF-8 with the
+// encoding it was converted from. Images are kept as data, the text of PDFs is
+// extracted and other binary files become a placeholder.
+func (r *Reader) readFile(path string) (FileContent, error) {
+	file := FileContent{Path: path}
+	handle, err := os.Open(path)
 	if err != nil {
-		return "", err
+		return file, err
 	}
-	defer file.Close()
+	defer handle.Close()
 
 	// Read content with size limit
-	limitedReader := io.LimitReader(file, r.maxFileSize)
+	limitedReader := io.LimitReader(handle, r.maxFileSize)
 	content, err := io.ReadAll(limitedReader)
 	if err != nil {
-		return "", err
+		return file, err
 	}
 
 	// Check if file is binary
-	if r.isBinary(content) {
-		return fmt.Sprintf("[Binary file: %s, size: %d bytes]", path, len(content)), nil
+	mime, encoding, binary := sniff(content)
+	switch {
+	case binary && IsImage(mime):
+		file.MIME, file.Image, file.Placeholder = mime, content, true
+		file.Content = fmt.Sprintf("[Image: %s, size: %d bytes, type: %s]", path, len(content), mime)
+		return file, nil
+	case binary && mime == MIMEPDF:
+		text, pages, err := extractPDF(content)
+		if err == nil && text != "" {
+			file.MIME, file.Pages, file.Content = mime, pages, text
+			return file, nil
+		}
+		// Scanned documents have no text layer
+		file.Placeholder = true
+		file.Content = fmt.Sprintf("[Binary file: %s, size: %d bytes, type: %s, no text found]", path, len(content), mime)
+		return file, nil
+	case binary:
+		file.Placeholder = true
+		file.Content = fmt.Sprintf("[Binary file: %s, size: %d bytes, type: %s]", path, len(content), mime)
+		return file, nil
 	}
 
-	return string(content), nil
-}
-
-// isBinary checks if content appears to be binary
-func (r *Reader) isBinary(content []byte) bool {
-	// Check for null bytes in first 512 bytes
-	checkSize := len(content)
-	if checkSize > 512 {
-		checkSize = 512
+	file.Content, err = toUTF8(content, encoding)
+	if err != nil {
+		return file, fmt.Errorf("invalid %s text: %w", encoding, err)
 	}
+	file.Encoding = encoding
+	return file, nil
+}
 
-	for i := 0; i < checkSize; i++ {
-		if content[i] == 0 {
-			return true
-		}
+// formatSize formats a size in bytes for messages, e.g. "512 B" or "1.50 MB"
+func formatSize(size int64) string {
+	switch {
+	case size < 1024:
+		return fmt.Sprintf("%d B", size)
+	case size < 1024*1024:
+		return fmt.Sprintf("%.2f KB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%.2f MB", float64(size)/1024/1024)
 	}
-
-	return false
 }
 
 // FormatFilesForAI formats file contents for AI consumption
@@ -138,14 +200,55 @@ func (r *Reader) FormatFilesForAI(files []FileContent) string {
 			builder.WriteString("\n" + strings.Repeat("-", 50) + "\n\n")
 		}
 
-		builder.WriteString(fmt.Sprintf("📁 **Файл: %s**\n", file.Path))
-		builder.WriteString(fmt.Sprintf("📊 Размер: %.2f KB\n\n", float64(file.Size)/1024))
+		switch file.Source {
+		case SourceStdin:
+			builder.WriteString(fmt.Sprintf("📥 **Ввод: %s**\n", file.Path))
+		case SourceRepo:
+			builder.WriteString(fmt.Sprintf("🗂 **Репозиторий: %s** (ветка, последние коммиты, статус, файлы и изменения)\n", file.Path))
+		case SourceCommand:
+			builder.WriteString(fmt.Sprintf("💻 **Вывод команды: `%s`**\n", file.Path))
+			builder.WriteString(fmt.Sprintf("🔚 Код выхода: %d\n", file.ExitCode))
+		default:
+			builder.WriteString(fmt.Sprintf("📁 **Файл: %s**\n", file.Path))
+		}
+		if file.Truncated {
+			builder.WriteString(fmt.Sprintf("✂️ Показан только конец: %.2f KB из %.2f KB\n", float64(len(file.Content))/1024, float64(file.Size)/1024))
+		}
+		if file.Start > 0 {
+			label := fmt.Sprintf("строки %d-%d", file.Start, file.End)
+			if file.Start == file.End {
+				label = fmt.Sprintf("строка %d", file.Start)
+			}
+			if file.Symbol != "" {
+				label = file.Symbol + ", " + label
+			}
+			builder.WriteString(fmt.Sprintf("📍 Фрагмент: %s (нумерация строк как в файле)\n", label))
+		}
+		builder.WriteString(fmt.Sprintf("📊 Размер: %.2f KB\n", float64(file.Size)/1024))
+		if file.MIME == MIMEPDF {
+			builder.WriteString(fmt.Sprintf("📄 Текст извлечен из PDF, страниц: %d\n", file.Pages))
+		}
+		if IsImage(file.MIME) && file.Image != nil {
+			builder.WriteString("🖼 Изображение приложено к сообщению\n")
+		} else if IsImage(file.MIME) {
+			builder.WriteString("🖼 Изображение не отправлено: модель не принимает изображения\n")
+		}
+		if file.Encoding != "" {
+			builder.WriteString(fmt.Sprintf("🔤 Кодировка: %s, преобразовано в UTF-8\n", file.Encoding))
+		}
+		if file.Outline {
+			builder.WriteString("✂️ Файл не поместился целиком: показаны только объявления, тела опущены\n")
+		}
+		builder.WriteString("\n")
 		
-		// Detect file type for syntax highlighting
-		ext := strings.ToLower(filepath.Ext(file.Path))
-		lang := r.detectLanguage(ext)
+		// Detect file type for syntax highlighting; command output and the
+		// repository summary are plain text
+		lang := "text"
+		if file.Source != SourceCommand && file.Source != SourceRepo && file.MIME != MIMEPDF {
+			lang = detectLanguage(file.Path, file.Content)
+		}
 		
-		if strings.HasPrefix(file.Content, "[Binary file:") {
+		if file.Placeholder {
 			builder.WriteString(file.Content)
 		} else {
 			builder.WriteString(fmt.Sprintf("```%s\n%s\n```", lang, file.Content))
@@ -158,68 +261,14 @@ func (r *Reader) FormatFilesForAI(files []FileContent) string {
 	return builder.String()
 }
 
-// detectLanguage detects programming language by file extension
-func (r *Reader) detectLanguage(ext string) string {
-	langMap := map[string]string{
-		".go":     "go",
-		".py":     "python",
-		".js":     "javascript",
-		".ts":     "typescript",
-		".java":   "java",
-		".cpp":    "cpp",
-		".c":      "c",
-		".cs":     "csharp",
-		".php":    "php",
-		".rb":     "ruby",
-		".rs":     "rust",
-		".swift":  "swift",
-		".kt":     "kotlin",
-		".scala":  "scala",
-		".sh":     "bash",
-		".bash":   "bash",
-		".zsh":    "bash",
-		".fish":   "fish",
-		".ps1":    "powershell",
-		".bat":    "batch",
-		".cmd":    "batch",
-		".html":   "html",
-		".htm":    "html",
-		".css":    "css",
-		".scss":   "scss",
-		".sass":   "sass",
-		".less":   "less",
-		".xml":    "xml",
-		".json":   "json",
-		".yaml":   "yaml",
-		".yml":    "yaml",
-		".toml":   "toml",
-		".ini":    "ini",
-		".cfg":    "ini",
-		".conf":   "ini",
-		".sql":    "sql",
-		".md":     "markdown",
-		".txt":    "text",
-		".log":    "text",
-		".env":    "bash",
-		".gitignore": "text",
-		".dockerfile": "dockerfile",
-		".makefile":  "makefile",
-	}
-
-	if lang, exists := langMap[ext]; exists {
-		return lang
-	}
-	return "text"
-}
-
 // ValidateFiles validates file paths before reading
 func (r *Reader) ValidateFiles(filePaths []string) error {
 	if len(filePaths) == 0 {
 		return fmt.Errorf("no files specified")
 	}
 
-	if len(filePaths) > 10 {
-		return fmt.Errorf("too many files specified (max: 10)")
+	if len(filePaths) > r.policy.maxFiles {
+		return fmt.Errorf("too many files specified (max: %d)", r.policy.maxFiles)
 	}
 
 	for _, path := range filePaths {
@@ -231,67 +280,17 @@ func (r *Reader) ValidateFiles(filePaths []string) error {
 	return nil
 }
 
-// validateSinglePath performs comprehensive path validation
-func (r *Reader) validateSinglePath(path string) error {
-	if strings.TrimSpace(path) == "" {
+// validateSinglePath checks a path against the reader's policy
+func (r *Reader) validateSinglePath(arg string) error {
+	if strings.TrimSpace(arg) == "" {
 		return fmt.Errorf("empty file path")
 	}
 
-	// Normalize path
-	cleanPath := filepath.Clean(path)
-	
-	// Get current working directory for path validation
-	cwd, err := os.Getwd()
+	// Only the path of "main.go:40-90" or "main.go#Symbol" is checked
+	path, _, err := SplitSelector(arg)
 	if err != nil {
-		return fmt.Errorf("cannot determine current directory: %w", err)
+		return err
 	}
-	
-	// Convert to absolute path for proper validation
-	var absPath string
-	if filepath.IsAbs(cleanPath) {
-		absPath = cleanPath
-	} else {
-		absPath = filepath.Join(cwd, cleanPath)
-	}
-	
-	// Normalize the absolute path
-	absPath = filepath.Clean(absPath)
-	
-	// Check if the resolved path is within the current directory
-	rel, err := filepath.Rel(cwd, absPath)
-	if err != nil {
-		return fmt.Errorf("invalid path resolution: %s", path)
-	}
-	
-	// Check for path traversal attempts (more robust check)
-	if strings.HasPrefix(rel, "..") || strings.Contains(rel, "..") {
-		return fmt.Errorf("path traversal not allowed: %s", path)
-	}
-	
-	// Additional check for symlinks that might escape
-	if strings.Contains(rel, "/") && (strings.HasPrefix(rel, "../") || strings.Contains(rel, "/../")) {
-		return fmt.Errorf("path traversal not allowed: %s", path)
-	}
-	
-	// Check for dangerous file extensions
-	ext := strings.ToLower(filepath.Ext(cleanPath))
-	dangerousExts := []string{".exe", ".bat", ".cmd", ".com", ".scr", ".pif", ".vbs", ".js"}
-	for _, dangerous := range dangerousExts {
-		if ext == dangerous {
-			return fmt.Errorf("potentially dangerous file type not allowed: %s", ext)
-		}
-	}
-	
-	// Check for system/hidden files
-	base := filepath.Base(cleanPath)
-	if strings.HasPrefix(base, ".") && base != ".env" && base != ".gitignore" {
-		return fmt.Errorf("hidden files not allowed: %s", path)
-	}
-	
-	// Check path length
-	if len(cleanPath) > 260 {
-		return fmt.Errorf("path too long (max 260 characters): %s", path)
-	}
-	
-	return nil
+
+	return r.policy.Check(filepath.Clean(path))
 }
@@ -0,0 +1,200 @@
+package files
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// selectorSuffix matches "path:40-90", "path:42" and "path#Symbol", where a
+// symbol may be qualified by its receiver type as in "Reader.ReadFiles"
+var selectorSuffix = regexp.MustCompile(`^(.+?)(?::(\d+)(?:-(\d+))?|#([A-Za-z_]\w*(?:\.[A-Za-z_]\w*)?))$`)
+
+// Selector narrows a file argument down to part of the file
+type Selector struct {
+	Start  int    // first line, 1-based; zero when selecting a symbol
+	End    int    // last line, inclusive
+	Symbol string // Go declaration, "Type.Method" for methods
+}
+
+// String returns the selector as written after the path
+func (s *Selector) String() string {
+	switch {
+	case s.Symbol != "":
+		return "#" + s.Symbol
+	case s.Start == s.End:
+		return fmt.Sprintf(":%d", s.Start)
+	default:
+		return fmt.Sprintf(":%d-%d", s.Start, s.End)
+	}
+}
+
+// SplitSelector splits a file argument such as "main.go:40-90" or
+// "main.go#runCommit" into the path and the selector. The selector is nil for
+// plain paths, including existing files whose name happens to look like a selector.
+func SplitSelector(arg string) (string, *Selector, error) {
+	parts := selectorSuffix.FindStringSubmatch(arg)
+	if parts == nil {
+		return arg, nil, nil
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return arg, nil, nil
+	}
+
+	if parts[4] != "" {
+		return parts[1], &Selector{Symbol: parts[4]}, nil
+	}
+
+	start, err := strconv.Atoi(parts[2])
+	if err != nil || start < 1 {
+		return "", nil, fmt.Errorf("invalid line range in %s: lines start at 1", arg)
+	}
+	end := start
+	if parts[3] != "" {
+		if end, err = strconv.Atoi(parts[3]); err != nil || end < start {
+			return "", nil, fmt.Errorf("invalid line range in %s: end before start", arg)
+		}
+	}
+	return parts[1], &Selector{Start: start, End: end}, nil
+}
+
+// excerpt narrows file down to the lines chosen by sel. Symbols are resolved by
+// parsing the file, and include their doc comment.
+func excerpt(file *FileContent, sel *Selector) error {
+	if file.Placeholder {
+		return fmt.Errorf("cannot select part of binary file %s", file.Path)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(file.Content, "\n"), "\n")
+	start, end := sel.Start, sel.End
+	if sel.Symbol != "" {
+		var err error
+		if start, end, err = findSymbol(file.Path, file.Content, sel.Symbol); err != nil {
+			return err
+		}
+	}
+	if start > len(lines) {
+		return fmt.Errorf("%s has only %d lines", file.Path, len(lines))
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	file.Content = strings.Join(lines[start-1:end], "\n")
+	file.Size = int64(len(file.Content))
+	file.Start, file.End, file.Symbol = start, end, sel.Symbol
+	return nil
+}
+
+// findSymbol returns the lines of a top-level Go declaration. A plain name matches
+// functions, types, constants, variables and methods; it must be unambiguous.
+func findSymbol(path, content, symbol string) (int, int, error) {
+	if filepath.Ext(path) != ".go" {
+		return 0, 0, fmt.Errorf("symbol selectors are only supported for Go files: %s#%s", path, symbol)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.ParseComments)
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+
+	receiver, name, qualified := strings.Cut(symbol, ".")
+	if !qualified {
+		receiver, name = "", symbol
+	}
+
+	type match struct {
+		name       string
+		start, end token.Pos
+	}
+	var matches []match
+	add := func(qualifiedName string, doc *ast.CommentGroup, node ast.Node) {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		matches = append(matches, match{qualifiedName, start, node.End()})
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Name.Name != name {
+				continue
+			}
+			if d.Recv == nil {
+				if !qualified {
+					add(name, d.Doc, d)
+				}
+				continue
+			}
+			if recv := ReceiverName(d.Recv.List[0].Type); !qualified || recv == receiver {
+				add(recv+"."+name, d.Doc, d)
+			}
+		case *ast.GenDecl:
+			if qualified {
+				continue
+			}
+			for _, spec := range d.Specs {
+				var names []*ast.Ident
+				var doc *ast.CommentGroup
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names, doc = []*ast.Ident{s.Name}, s.Doc
+				case *ast.ValueSpec:
+					names, doc = s.Names, s.Doc
+				}
+				for _, ident := range names {
+					if ident.Name != name {
+						continue
+					}
+					// A lone spec is shown with its keyword, one in a group on its own
+					if d.Lparen.IsValid() {
+						add(name, doc, spec)
+					} else {
+						add(name, d.Doc, d)
+					}
+				}
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return 0, 0, fmt.Errorf("symbol %s not found in %s", symbol, path)
+	case 1:
+		return fset.Position(matches[0].start).Line, fset.Position(matches[0].end).Line, nil
+	default:
+		var names []string
+		for _, m := range matches {
+			names = append(names, m.name)
+		}
+		return 0, 0, fmt.Errorf("symbol %s is ambiguous in %s: %s", symbol, path, strings.Join(names, ", "))
+	}
+}
+
+// ReceiverName returns the type name of a method receiver such as "*Reader" or
+// "List[T]"
+func ReceiverName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
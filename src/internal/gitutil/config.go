@@ -0,0 +1,339 @@
+package gitutil
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/gcfg"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// maxIncludeDepth matches git's limit on nested include.path directives
+const maxIncludeDepth = 10
+
+// Config is a resolved view of git configuration. Files are read in git's order
+// (system, global, local) and include.path / includeIf.<condition>.path directives
+// are expanded in place, followed by GIT_CONFIG_COUNT environment overrides.
+type Config struct {
+	values map[string][]string // normalized key -> values in the order they were read
+	gitDir string
+	branch string
+}
+
+// LoadConfig resolves the configuration that git would use for repo
+func LoadConfig(repo *git.Repository) (*Config, error) {
+	cfg := &Config{values: map[string][]string{}}
+
+	if storage, ok := repo.Storer.(*filesystem.Storage); ok {
+		cfg.gitDir = storage.Filesystem().Root()
+	}
+	if head, err := repo.Head(); err == nil && head.Name().IsBranch() {
+		cfg.branch = head.Name().Short()
+	} else if ref, err := repo.Storer.Reference("HEAD"); err == nil && ref.Target().IsBranch() {
+		// Unborn branch: HEAD points at a branch that has no commits yet
+		cfg.branch = ref.Target().Short()
+	}
+
+	for _, path := range configFiles(cfg.gitDir) {
+		if err := cfg.readFile(path, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.readEnv(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Get returns the last value of key ("section.key" or "section.subsection.key")
+func (c *Config) Get(key string) string {
+	values := c.values[normalizeKey(key)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// GetAll returns every value of a multi-valued key
+func (c *Config) GetAll(key string) []string {
+	return c.values[normalizeKey(key)]
+}
+
+// Has reports whether key is set
+func (c *Config) Has(key string) bool {
+	return len(c.values[normalizeKey(key)]) > 0
+}
+
+// Bool interprets key as a git boolean; unset keys are false
+func (c *Config) Bool(key string) bool {
+	switch strings.ToLower(strings.TrimSpace(c.Get(key))) {
+	case "true", "yes", "on", "1":
+		return true
+	}
+	return false
+}
+
+// Signing returns the commit signing settings
+func (c *Config) Signing() SigningConfig {
+	cfg := SigningConfig{
+		Enabled: c.Bool("commit.gpgsign"),
+		Format:  strings.ToLower(c.Get("gpg.format")),
+		Key:     c.Get("user.signingkey"),
+	}
+
+	// gpg.<format>.program wins over the legacy gpg.program
+	format := cfg.Format
+	if format == "" {
+		format = FormatOpenPGP
+	}
+	cfg.Program = c.Get("gpg." + format + ".program")
+	if cfg.Program == "" && format == FormatOpenPGP {
+		cfg.Program = c.Get("gpg.program")
+	}
+
+	return cfg
+}
+
+// configFiles lists config files in increasing order of precedence
+func configFiles(gitDir string) []string {
+	var files []string
+
+	if os.Getenv("GIT_CONFIG_NOSYSTEM") == "" {
+		if system := os.Getenv("GIT_CONFIG_SYSTEM"); system != "" {
+			files = append(files, system)
+		} else {
+			files = append(files, "/etc/gitconfig")
+		}
+	}
+
+	if global := os.Getenv("GIT_CONFIG_GLOBAL"); global != "" {
+		files = append(files, global)
+	} else {
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			files = append(files, filepath.Join(xdg, "git", "config"))
+		} else if home, err := os.UserHomeDir(); err == nil {
+			files = append(files, filepath.Join(home, ".config", "git", "config"))
+		}
+		if home, err := os.UserHomeDir(); err == nil {
+			files = append(files, filepath.Join(home, ".gitconfig"))
+		}
+	}
+
+	if gitDir != "" {
+		files = append(files, filepath.Join(gitDir, "config"))
+	}
+
+	return files
+}
+
+// readFile reads one config file, expanding includes where they appear.
+// Missing files are skipped, as git does.
+func (c *Config) readFile(path string, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("exceeded maximum include depth (%d) while including %s", maxIncludeDepth, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) || os.IsPermission(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read git config %s: %w", path, err)
+	}
+
+	err = gcfg.ReadWithCallback(bytes.NewReader(data), func(section, subsection, key, value string, blank bool) error {
+		if key == "" {
+			return nil
+		}
+		if blank {
+			// "[section] key" without a value means true
+			value = "true"
+		}
+
+		section = strings.ToLower(section)
+		if strings.ToLower(key) == "path" {
+			switch {
+			case section == "include" && subsection == "":
+				return c.readFile(c.includePath(path, value), depth+1)
+			case section == "includeif":
+				if c.conditionMatches(subsection, path) {
+					return c.readFile(c.includePath(path, value), depth+1)
+				}
+				return nil
+			}
+		}
+
+		c.add(section, subsection, key, value)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to parse git config %s: %w", path, err)
+	}
+	return nil
+}
+
+// readEnv applies GIT_CONFIG_COUNT / GIT_CONFIG_KEY_<n> / GIT_CONFIG_VALUE_<n>
+func (c *Config) readEnv() error {
+	raw := os.Getenv("GIT_CONFIG_COUNT")
+	if raw == "" {
+		return nil
+	}
+
+	count, err := strconv.Atoi(raw)
+	if err != nil || count < 0 {
+		return fmt.Errorf("invalid GIT_CONFIG_COUNT %q", raw)
+	}
+
+	for i := 0; i < count; i++ {
+		key := os.Getenv(fmt.Sprintf("GIT_CONFIG_KEY_%d", i))
+		if key == "" {
+			return fmt.Errorf("missing config key GIT_CONFIG_KEY_%d", i)
+		}
+		c.values[normalizeKey(key)] = append(c.values[normalizeKey(key)], os.Getenv(fmt.Sprintf("GIT_CONFIG_VALUE_%d", i)))
+	}
+	return nil
+}
+
+func (c *Config) add(section, subsection, key, value string) {
+	name := section
+	if subsection != "" {
+		name += "." + subsection
+	}
+	name += "." + strings.ToLower(key)
+	c.values[name] = append(c.values[name], value)
+}
+
+// includePath resolves an include path relative to the including file
+func (c *Config) includePath(from, path string) string {
+	path = expandHome(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(from), path)
+	}
+	return path
+}
+
+// conditionMatches evaluates an includeIf condition such as "gitdir:~/work/"
+func (c *Config) conditionMatches(condition, from string) bool {
+	kind, pattern, ok := strings.Cut(condition, ":")
+	if !ok {
+		return false
+	}
+
+	switch kind {
+	case "gitdir", "gitdir/i":
+		if c.gitDir == "" {
+			return false
+		}
+		pattern = expandHome(pattern)
+		if strings.HasPrefix(pattern, "./") {
+			pattern = filepath.Join(filepath.Dir(from), pattern[2:])
+		}
+		pattern = filepath.ToSlash(pattern)
+		if !strings.HasPrefix(pattern, "/") && !isWindowsAbs(pattern) {
+			pattern = "**/" + pattern
+		}
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "**"
+		}
+
+		gitDir := filepath.ToSlash(c.gitDir)
+		if resolved, err := filepath.EvalSymlinks(c.gitDir); err == nil {
+			// git matches both the given and the symlink-resolved path
+			if matchGlob(pattern, filepath.ToSlash(resolved), kind == "gitdir/i") {
+				return true
+			}
+		}
+		return matchGlob(pattern, gitDir, kind == "gitdir/i")
+	case "onbranch":
+		if c.branch == "" {
+			return false
+		}
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "**"
+		}
+		return matchGlob(pattern, c.branch, false)
+	}
+
+	// hasconfig: and unknown conditions never match
+	return false
+}
+
+// matchGlob matches name against a wildmatch pattern where "**" spans directories
+func matchGlob(pattern, name string, foldCase bool) bool {
+	var expr strings.Builder
+	if foldCase {
+		expr.WriteString("(?i)")
+	}
+	expr.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case ch == '*':
+			expr.WriteString("[^/]*")
+		case ch == '?':
+			expr.WriteString("[^/]")
+		case ch == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return false
+	}
+	return re.MatchString(name)
+}
+
+// normalizeKey lowercases the section and key names; subsections are case-sensitive
+func normalizeKey(key string) string {
+	first := strings.Index(key, ".")
+	last := strings.LastIndex(key, ".")
+	if first < 0 {
+		return strings.ToLower(key)
+	}
+	if first == last {
+		return strings.ToLower(key)
+	}
+	return strings.ToLower(key[:first]) + key[first:last] + strings.ToLower(key[last:])
+}
+
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+func isWindowsAbs(path string) bool {
+	return len(path) >= 3 && path[1] == ':' && path[2] == '/'
+}
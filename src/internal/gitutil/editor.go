@@ -0,0 +1,27 @@
+package gitutil
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Editor returns the editor git would launch: GIT_EDITOR, core.editor,
+// VISUAL, EDITOR and finally vi
+func (c *Config) Editor() string {
+	return firstNonEmpty(os.Getenv("GIT_EDITOR"), c.Get("core.editor"), os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi")
+}
+
+// RunEditor opens path in editor. Like git, the editor value is run by the shell
+// so that settings such as "code --wait" work.
+func RunEditor(editor, path string) error {
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package gitutil
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// EmptyTree is the hash of the empty tree, used to diff root commits
+const EmptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// PublishedIn returns the first remote-tracking branch that already contains
+// commit, or "" when the commit has not been pushed anywhere we know of
+func PublishedIn(repo *git.Repository, commit *object.Commit) (string, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return "", err
+	}
+	defer refs.Close()
+
+	published := ""
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if !ref.Name().IsRemote() || ref.Type() != plumbing.HashReference {
+			return nil
+		}
+
+		remote, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return nil // remote refs may point at objects we do not have
+		}
+
+		contained := remote.Hash == commit.Hash
+		if !contained {
+			if contained, err = commit.IsAncestor(remote); err != nil {
+				return err
+			}
+		}
+		if contained {
+			published = ref.Name().Short()
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return published, nil
+}
+
+// AncestryPath returns the commits from target up to HEAD, oldest first, following
+// first parents. It fails when target is not on that line or when the path contains
+// merge commits, which cannot be rewritten by replaying a single parent.
+func AncestryPath(repo *git.Repository, target plumbing.Hash) ([]*object.Commit, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	var path []*object.Commit
+	hash := head.Hash()
+	for {
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		if commit.NumParents() > 1 {
+			return nil, fmt.Errorf("commit %s is a merge; history containing merges cannot be reworded", commit.Hash.String()[:8])
+		}
+		path = append([]*object.Commit{commit}, path...)
+
+		if commit.Hash == target {
+			return path, nil
+		}
+		if commit.NumParents() == 0 {
+			return nil, fmt.Errorf("commit %s is not an ancestor of HEAD", target.String()[:8])
+		}
+		hash = commit.ParentHashes[0]
+	}
+}
+
+// RewriteMessage replaces the message of the first commit in path and recreates the
+// remaining commits on top of it, as "git rebase" would: trees, authors and the other
+// messages are kept, the committer is updated and commits are re-signed when signer
+// is set. The branch HEAD points to is moved to the new tip, which is returned.
+func RewriteMessage(repo *git.Repository, path []*object.Commit, message string, committer object.Signature, signer git.Signer) (plumbing.Hash, error) {
+	if len(path) == 0 {
+		return plumbing.ZeroHash, fmt.Errorf("nothing to rewrite")
+	}
+
+	oldTip := path[len(path)-1].Hash
+	var parent plumbing.Hash
+	for i, original := range path {
+		rewritten := *original
+		rewritten.Committer = committer
+		rewritten.PGPSignature = ""
+		if i == 0 {
+			rewritten.Message = message
+		} else {
+			rewritten.ParentHashes = []plumbing.Hash{parent}
+		}
+
+		hash, err := storeCommit(repo, &rewritten, signer)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to rewrite %s: %w", original.Hash.String()[:8], err)
+		}
+		parent = hash
+	}
+
+	// Move the checked-out branch, or HEAD itself when detached
+	name := plumbing.HEAD
+	if ref, err := repo.Storer.Reference(plumbing.HEAD); err == nil && ref.Type() == plumbing.SymbolicReference {
+		name = ref.Target()
+	}
+	if err := repo.Storer.CheckAndSetReference(
+		plumbing.NewHashReference(name, parent),
+		plumbing.NewHashReference(name, oldTip),
+	); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to update %s: %w", name.Short(), err)
+	}
+
+	return parent, nil
+}
+
+// storeCommit writes commit to the object store, signing it first when signer is set
+func storeCommit(repo *git.Repository, commit *object.Commit, signer git.Signer) (plumbing.Hash, error) {
+	if signer != nil {
+		unsigned := repo.Storer.NewEncodedObject()
+		if err := commit.EncodeWithoutSignature(unsigned); err != nil {
+			return plumbing.ZeroHash, err
+		}
+		reader, err := unsigned.Reader()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		signature, err := signer.Sign(reader)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		commit.PGPSignature = string(signature)
+	}
+
+	obj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return repo.Storer.SetEncodedObject(obj)
+}
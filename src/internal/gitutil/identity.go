@@ -0,0 +1,114 @@
+package gitutil
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Identity holds the author and committer signatures for a new commit
+type Identity struct {
+	Author    object.Signature
+	Committer object.Signature
+}
+
+// Identity resolves author and committer exactly as "git commit" does:
+// GIT_AUTHOR_* / GIT_COMMITTER_* environment variables first, then
+// author.* / committer.* config, then user.*, and finally $EMAIL for the address.
+func (c *Config) Identity() (*Identity, error) {
+	now := time.Now()
+
+	author, err := c.signature("author", now)
+	if err != nil {
+		return nil, err
+	}
+	committer, err := c.signature("committer", now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{Author: author, Committer: committer}, nil
+}
+
+// signature resolves one role ("author" or "committer")
+func (c *Config) signature(role string, now time.Time) (object.Signature, error) {
+	env := "GIT_" + strings.ToUpper(role) + "_"
+
+	name := firstNonEmpty(os.Getenv(env+"NAME"), c.Get(role+".name"), c.Get("user.name"))
+	email := firstNonEmpty(os.Getenv(env+"EMAIL"), c.Get(role+".email"), c.Get("user.email"), os.Getenv("EMAIL"))
+
+	if name == "" || email == "" {
+		return object.Signature{}, fmt.Errorf("%s identity unknown. Please configure it using:\n"+
+			"  git config --global user.name \"Your Name\"\n"+
+			"  git config --global user.email \"you@example.com\"", role)
+	}
+
+	when := now
+	if raw := os.Getenv(env + "DATE"); raw != "" {
+		parsed, err := ParseGitDate(raw)
+		if err != nil {
+			return object.Signature{}, fmt.Errorf("invalid %sDATE: %w", env, err)
+		}
+		when = parsed
+	}
+
+	return object.Signature{Name: name, Email: email, When: when}, nil
+}
+
+// CommitterIdent returns "Name <email>" of the committer, used as the default signing key
+func (i *Identity) CommitterIdent() string {
+	return fmt.Sprintf("%s <%s>", i.Committer.Name, i.Committer.Email)
+}
+
+// ParseGitDate parses the date formats accepted by GIT_AUTHOR_DATE and GIT_COMMITTER_DATE:
+// git's internal "<unix> <+zone>" (optionally prefixed with "@"), RFC 2822 and ISO 8601.
+func ParseGitDate(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+
+	fields := strings.Fields(strings.TrimPrefix(raw, "@"))
+	if len(fields) >= 1 && len(fields) <= 2 {
+		if seconds, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			when := time.Unix(seconds, 0)
+			if len(fields) == 2 {
+				zone, err := time.Parse("-0700", fields[1])
+				if err != nil {
+					return time.Time{}, fmt.Errorf("invalid time zone %q", fields[1])
+				}
+				when = when.In(zone.Location())
+			}
+			return when, nil
+		}
+	}
+
+	layouts := []string{
+		time.RFC1123Z,
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"2 Jan 2006 15:04:05 -0700",
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05 -0700",
+		"2006-01-02 15:04:05",
+		"2006.01.02 15:04:05",
+		"01/02/2006 15:04:05",
+	}
+	for _, layout := range layouts {
+		if when, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return when, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognised date %q", raw)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
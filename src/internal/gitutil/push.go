@@ -0,0 +1,29 @@
+package gitutil
+
+import "strings"
+
+// PushTarget is where a branch is pushed
+type PushTarget struct {
+	Remote   string
+	Branch   string // local branch name
+	Upstream string // branch of Remote the local branch tracks; "" when it has none there
+	Tracking bool   // the branch tracks something, possibly on another remote
+}
+
+// PushTarget resolves the remote "git push" would use for branch, unless remote is
+// given: branch.<name>.pushRemote, remote.pushDefault, branch.<name>.remote and
+// finally origin. Upstream comes from branch.<name>.merge when it belongs to that remote;
+// Tracking is set when branch.<name>.remote or branch.<name>.merge is.
+func (c *Config) PushTarget(branch, remote string) PushTarget {
+	tracked := c.Get("branch." + branch + ".remote")
+	if remote == "" {
+		remote = firstNonEmpty(c.Get("branch."+branch+".pushRemote"), c.Get("remote.pushDefault"), tracked, "origin")
+	}
+
+	merge := c.Get("branch." + branch + ".merge")
+	target := PushTarget{Remote: remote, Branch: branch, Tracking: tracked != "" || merge != ""}
+	if tracked == remote && strings.HasPrefix(merge, "refs/heads/") {
+		target.Upstream = strings.TrimPrefix(merge, "refs/heads/")
+	}
+	return target
+}
@@ -0,0 +1,175 @@
+package gitutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"zero-workflow/src/pkg/errors"
+)
+
+// Signature formats supported by gpg.format
+const (
+	FormatOpenPGP = "openpgp"
+	FormatX509    = "x509"
+	FormatSSH     = "ssh"
+)
+
+// SigningConfig mirrors the git settings that control commit signing
+type SigningConfig struct {
+	Enabled bool   // commit.gpgsign
+	Format  string // gpg.format
+	Program string // gpg.program or gpg.<format>.program
+	Key     string // user.signingkey
+}
+
+// ProgramSigner signs commits the way git does: by piping the payload to the
+// configured signing program. It implements go-git's Signer interface.
+type ProgramSigner struct {
+	format  string
+	program string
+	key     string
+}
+
+// NewSigner creates a signer for cfg. ident ("Name <email>") is used as the
+// OpenPGP key id when user.signingkey is not set, matching git's behaviour.
+func NewSigner(cfg SigningConfig, ident string) (*ProgramSigner, error) {
+	format := strings.ToLower(cfg.Format)
+	if format == "" {
+		format = FormatOpenPGP
+	}
+
+	program := cfg.Program
+	switch format {
+	case FormatOpenPGP:
+		if program == "" {
+			program = "gpg"
+		}
+	case FormatX509:
+		if program == "" {
+			program = "gpgsm"
+		}
+	case FormatSSH:
+		if program == "" {
+			program = "ssh-keygen"
+		}
+		if cfg.Key == "" {
+			return nil, fmt.Errorf("user.signingkey must be set for ssh signing")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported gpg.format %q (supported: openpgp, x509, ssh)", cfg.Format)
+	}
+
+	key := cfg.Key
+	if key == "" {
+		key = ident
+	}
+
+	return &ProgramSigner{format: format, program: program, key: key}, nil
+}
+
+// Sign returns an armored detached signature for the message
+func (s *ProgramSigner) Sign(message io.Reader) ([]byte, error) {
+	payload, err := io.ReadAll(message)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.format == FormatSSH {
+		return s.signSSH(payload)
+	}
+	return s.signGPG(payload)
+}
+
+// signGPG runs "gpg --status-fd=2 -bsau <key>" with the payload on stdin
+func (s *ProgramSigner) signGPG(payload []byte) ([]byte, error) {
+	args := []string{"--status-fd=2", "-bsau", s.key}
+	if err := errors.ValidateSigningCommand(s.program, args); err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(s.program, args...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed to sign the data: %s", s.program, strings.TrimSpace(stderr.String()))
+	}
+	// gpg may exit 0 without signing (e.g. cancelled pinentry); git checks the status line too
+	if !strings.Contains(stderr.String(), "[GNUPG:] SIG_CREATED ") {
+		return nil, fmt.Errorf("%s did not create a signature", s.program)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// signSSH runs "ssh-keygen -Y sign -n git -f <key> <file>", which writes <file>.sig
+func (s *ProgramSigner) signSSH(payload []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "zw-sign-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	payloadFile := filepath.Join(dir, "payload")
+	if err := os.WriteFile(payloadFile, payload, 0600); err != nil {
+		return nil, err
+	}
+
+	keyFile, literal, err := s.sshKeyFile(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"-Y", "sign", "-n", "git", "-f", keyFile}
+	if literal {
+		// A literal public key means the private half lives in ssh-agent
+		args = append(args, "-U")
+	}
+	args = append(args, payloadFile)
+
+	if err := errors.ValidateSigningCommand(s.program, args); err != nil {
+		return nil, err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(s.program, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed to sign the data: %s", s.program, strings.TrimSpace(stderr.String()))
+	}
+
+	signature, err := os.ReadFile(payloadFile + ".sig")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ssh signature: %w", err)
+	}
+	return signature, nil
+}
+
+// sshKeyFile resolves user.signingkey to a file path. Literal keys ("key::ssh-ed25519 ..."
+// or "ssh-ed25519 ...") are written to a temporary file inside dir.
+func (s *ProgramSigner) sshKeyFile(dir string) (string, bool, error) {
+	key := strings.TrimSpace(s.key)
+	if strings.HasPrefix(key, "key::") || strings.HasPrefix(key, "ssh-") || strings.HasPrefix(key, "ecdsa-") || strings.HasPrefix(key, "sk-") {
+		keyFile := filepath.Join(dir, "key.pub")
+		if err := os.WriteFile(keyFile, []byte(strings.TrimPrefix(key, "key::")+"\n"), 0600); err != nil {
+			return "", false, err
+		}
+		return keyFile, true, nil
+	}
+
+	if strings.HasPrefix(key, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false, fmt.Errorf("cannot expand user.signingkey: %w", err)
+		}
+		key = filepath.Join(home, key[2:])
+	}
+	return key, false, nil
+}
@@ -0,0 +1,104 @@
+package gitutil
+
+import (
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// TagsByCommit maps commit hashes to the names of the tags pointing at them.
+// Annotated tags are peeled to their commits; tags of other objects are ignored.
+func TagsByCommit(repo *git.Repository) (map[plumbing.Hash][]string, error) {
+	iter, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	tags := map[plumbing.Hash][]string{}
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		hash := ref.Hash()
+		if tag, err := repo.TagObject(hash); err == nil {
+			commit, err := tag.Commit()
+			if err != nil {
+				return nil
+			}
+			hash = commit.Hash
+		}
+		tags[hash] = append(tags[hash], ref.Name().Short())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for hash := range tags {
+		sort.Strings(tags[hash])
+	}
+	return tags, nil
+}
+
+// NearestTag walks the history of from (excluding from itself), newest first, and
+// returns the first commit carrying a tag accepted by match, like "git describe"
+// on from's parents. It returns a nil commit when no such tag exists.
+func NearestTag(repo *git.Repository, from *object.Commit, match func(string) bool) (string, *object.Commit, error) {
+	tags, err := TagsByCommit(repo)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var name string
+	var found *object.Commit
+	iter := object.NewCommitIterCTime(from, nil, nil)
+	defer iter.Close()
+
+	err = iter.ForEach(func(commit *object.Commit) error {
+		if commit.Hash == from.Hash {
+			return nil
+		}
+		for _, tag := range tags[commit.Hash] {
+			if match == nil || match(tag) {
+				name, found = tag, commit
+				return storer.ErrStop
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return name, found, nil
+}
+
+// CommitRange returns the commits reachable from to but not from from, newest
+// first, like "git log from..to". A nil from selects the whole history of to.
+func CommitRange(from, to *object.Commit) ([]*object.Commit, error) {
+	excluded := map[plumbing.Hash]bool{}
+	if from != nil {
+		iter := object.NewCommitPreorderIter(from, nil, nil)
+		err := iter.ForEach(func(commit *object.Commit) error {
+			excluded[commit.Hash] = true
+			return nil
+		})
+		iter.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var commits []*object.Commit
+	iter := object.NewCommitIterCTime(to, excluded, nil)
+	defer iter.Close()
+
+	err := iter.ForEach(func(commit *object.Commit) error {
+		commits = append(commits, commit)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
@@ -0,0 +1,230 @@
+package gitutil
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"zero-workflow/src/internal/files"
+)
+
+// WorktreeDiff returns the staged (HEAD to index) and unstaged (index to
+// worktree) changes of tracked files as unified diffs, like "git diff --staged"
+// and "git diff". Untracked files are not included.
+func WorktreeDiff(repo *git.Repository, status git.Status) (string, string, error) {
+	var headTree *object.Tree
+	if head, err := repo.Head(); err == nil {
+		commit, err := repo.CommitObject(head.Hash())
+		if err != nil {
+			return "", "", err
+		}
+		if headTree, err = commit.Tree(); err != nil {
+			return "", "", err
+		}
+	} else if err != plumbing.ErrReferenceNotFound {
+		return "", "", err
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return "", "", err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", "", err
+	}
+	root := worktree.Filesystem.Root()
+
+	paths := make([]string, 0, len(status))
+	for path := range status {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var staged, unstaged []fdiff.FilePatch
+	for _, path := range paths {
+		fileStatus := status[path]
+		if fileStatus.Staging == git.Untracked {
+			continue
+		}
+
+		entry, _ := idx.Entry(path)
+		indexed, err := indexSide(repo, entry)
+		if err != nil {
+			return "", "", err
+		}
+
+		if fileStatus.Staging != git.Unmodified {
+			head, err := treeSide(headTree, path)
+			if err != nil {
+				return "", "", err
+			}
+			staged = append(staged, newFilePatch(head, indexed))
+		}
+		if fileStatus.Worktree != git.Unmodified {
+			current, err := worktreeSide(root, path, entry)
+			if err != nil {
+				return "", "", err
+			}
+			unstaged = append(unstaged, newFilePatch(indexed, current))
+		}
+	}
+
+	stagedDiff, err := encodePatch(staged)
+	if err != nil {
+		return "", "", err
+	}
+	unstagedDiff, err := encodePatch(unstaged)
+	if err != nil {
+		return "", "", err
+	}
+	return stagedDiff, unstagedDiff, nil
+}
+
+// side is one version of a file in a diff; nil when the file does not exist there
+type side struct {
+	file    *diffFile
+	content string
+}
+
+func treeSide(tree *object.Tree, path string) (*side, error) {
+	if tree == nil {
+		return nil, nil
+	}
+	file, err := tree.File(path)
+	if err == object.ErrFileNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return &side{&diffFile{file.Hash, file.Mode, path}, content}, nil
+}
+
+func indexSide(repo *git.Repository, entry *index.Entry) (*side, error) {
+	if entry == nil {
+		return nil, nil
+	}
+	blob, err := repo.BlobObject(entry.Hash)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &side{&diffFile{entry.Hash, entry.Mode, entry.Name}, string(content)}, nil
+}
+
+func worktreeSide(root, path string, entry *index.Entry) (*side, error) {
+	content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	mode := filemode.Regular
+	if entry != nil {
+		mode = entry.Mode
+	}
+	hash := plumbing.ComputeHash(plumbing.BlobObject, content)
+	return &side{&diffFile{hash, mode, path}, string(content)}, nil
+}
+
+// diffFile, filePatch and chunk implement the interfaces of go-git's diff
+// format package, so that its unified encoder can print index and worktree changes
+type diffFile struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+	path string
+}
+
+func (f *diffFile) Hash() plumbing.Hash     { return f.hash }
+func (f *diffFile) Mode() filemode.FileMode { return f.mode }
+func (f *diffFile) Path() string            { return f.path }
+
+type chunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c chunk) Content() string       { return c.content }
+func (c chunk) Type() fdiff.Operation { return c.op }
+
+type filePatch struct {
+	from, to fdiff.File
+	chunks   []fdiff.Chunk
+	binary   bool
+}
+
+func (p *filePatch) IsBinary() bool                  { return p.binary }
+func (p *filePatch) Files() (fdiff.File, fdiff.File) { return p.from, p.to }
+func (p *filePatch) Chunks() []fdiff.Chunk           { return p.chunks }
+
+type patchSet []fdiff.FilePatch
+
+func (p patchSet) FilePatches() []fdiff.FilePatch { return p }
+func (p patchSet) Message() string                { return "" }
+
+func newFilePatch(from, to *side) fdiff.FilePatch {
+	patch := &filePatch{}
+	var fromContent, toContent string
+	// Leave missing sides as nil interfaces, which the encoder expects
+	if from != nil {
+		patch.from, fromContent = from.file, from.content
+	}
+	if to != nil {
+		patch.to, toContent = to.file, to.content
+	}
+
+	// Text in UTF-16 or a single-byte encoding is compared as UTF-8
+	fromContent, fromText := files.DecodeText([]byte(fromContent))
+	toContent, toText := files.DecodeText([]byte(toContent))
+	if !fromText || !toText {
+		patch.binary = true
+		return patch
+	}
+
+	for _, d := range diff.Do(fromContent, toContent) {
+		op := fdiff.Equal
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			op = fdiff.Add
+		case diffmatchpatch.DiffDelete:
+			op = fdiff.Delete
+		}
+		patch.chunks = append(patch.chunks, chunk{d.Text, op})
+	}
+	return patch
+}
+
+func encodePatch(patches []fdiff.FilePatch) (string, error) {
+	if len(patches) == 0 {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := fdiff.NewUnifiedEncoder(&buf, fdiff.DefaultContextLines).Encode(patchSet(patches)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,30 @@
+package identifier
+
+import "unicode"
+
+// Split splits a camelCase or snake_case identifier into its words, e.g.
+// "parseHTTPRequest" into "parse", "HTTP" and "Request". Empty words around
+// underscores are dropped, so "__init__" gives just "init".
+func Split(word string) []string {
+	var parts []string
+	var current []rune
+	runes := []rune(word)
+	for i, r := range runes {
+		switch {
+		case r == '_':
+			if len(current) > 0 {
+				parts = append(parts, string(current))
+			}
+			current = nil
+			continue
+		case i > 0 && len(current) > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))):
+			parts = append(parts, string(current))
+			current = nil
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		parts = append(parts, string(current))
+	}
+	return parts
+}
@@ -0,0 +1,118 @@
+package index
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"zero-workflow/src/internal/identifier"
+)
+
+// BM25 parameters, the usual defaults
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// bm25 holds the term statistics of all chunks, computed when the index is loaded
+type bm25 struct {
+	freqs     []map[string]int // term frequencies per chunk
+	lengths   []int
+	docFreq   map[string]int
+	avgLength float64
+}
+
+func newBM25(chunks []*Chunk) *bm25 {
+	b := &bm25{
+		freqs:   make([]map[string]int, len(chunks)),
+		lengths: make([]int, len(chunks)),
+		docFreq: map[string]int{},
+	}
+
+	total := 0
+	for i, chunk := range chunks {
+		freq := map[string]int{}
+		// The path and symbol name say what a chunk is about, so they count twice
+		header := chunk.Path + " " + chunk.Symbol
+		for _, term := range append(terms(header), terms(header)...) {
+			freq[term]++
+		}
+		for _, term := range terms(chunk.Text) {
+			freq[term]++
+		}
+
+		length := 0
+		for term, n := range freq {
+			b.docFreq[term]++
+			length += n
+		}
+		b.freqs[i], b.lengths[i] = freq, length
+		total += length
+	}
+	if len(chunks) > 0 {
+		b.avgLength = float64(total) / float64(len(chunks))
+	}
+	return b
+}
+
+// scores returns the BM25 score of every chunk for the query
+func (b *bm25) scores(query string) []float64 {
+	scores := make([]float64, len(b.freqs))
+	n := float64(len(b.freqs))
+
+	for _, term := range uniqueTerms(query) {
+		df := float64(b.docFreq[term])
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for i, freq := range b.freqs {
+			tf := float64(freq[term])
+			if tf == 0 {
+				continue
+			}
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(b.lengths[i])/b.avgLength)
+			scores[i] += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+	}
+	return scores
+}
+
+// terms splits text into lowercase words. Identifiers are kept whole and also
+// split at camelCase and snake_case boundaries, so "ReadFiles" matches "read files".
+func terms(text string) []string {
+	var result []string
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, word := range words {
+		parts := identifier.Split(word)
+		if len(parts) > 1 {
+			result = appendTerm(result, word)
+		}
+		for _, part := range parts {
+			result = appendTerm(result, part)
+		}
+	}
+	return result
+}
+
+func uniqueTerms(text string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, term := range terms(text) {
+		if !seen[term] {
+			seen[term] = true
+			result = append(result, term)
+		}
+	}
+	return result
+}
+
+func appendTerm(result []string, term string) []string {
+	term = strings.ToLower(term)
+	if len([]rune(term)) < 2 {
+		return result
+	}
+	return append(result, term)
+}
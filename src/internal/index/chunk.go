@@ -0,0 +1,154 @@
+package index
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"strings"
+
+	"zero-workflow/src/internal/files"
+)
+
+const (
+	targetChunkLines = 40  // paragraphs are merged up to about this many lines
+	maxChunkLines    = 120 // longer declarations and paragraphs are split
+)
+
+// Chunk is a piece of a source file that is indexed and retrieved on its own
+type Chunk struct {
+	Path      string // slash-separated, relative to the repository root
+	Start     int    // first line, 1-based
+	End       int    // last line, inclusive
+	Symbol    string // Go declaration or Markdown heading, if any
+	Text      string
+	Embedding []float32
+}
+
+// Split cuts a file into chunks: Go files by top-level declaration, other
+// files by paragraph. Markdown headings always start a new chunk.
+func Split(filePath, content string) []*Chunk {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if path.Ext(filePath) == ".go" {
+		if chunks, err := splitGo(filePath, content, lines); err == nil && len(chunks) > 0 {
+			return chunks
+		}
+	}
+	return splitParagraphs(filePath, lines)
+}
+
+// splitGo makes a chunk of every top-level declaration with its doc comment.
+// Imports are left out: they rarely answer a question.
+func splitGo(filePath, content string, lines []string) ([]*Chunk, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []*Chunk
+	for _, decl := range file.Decls {
+		start := decl.Pos()
+		var symbol string
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			symbol = d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				symbol = files.ReceiverName(d.Recv.List[0].Type) + "." + symbol
+			}
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			symbol = genDeclName(d)
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+
+		from := fset.Position(start).Line
+		to := fset.Position(decl.End()).Line
+		chunks = append(chunks, window(filePath, symbol, lines, from, to)...)
+	}
+	return chunks, nil
+}
+
+// genDeclName names a type, const or var declaration after its first name
+func genDeclName(d *ast.GenDecl) string {
+	for _, spec := range d.Specs {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			return s.Name.Name
+		case *ast.ValueSpec:
+			if len(s.Names) > 0 {
+				return s.Names[0].Name
+			}
+		}
+	}
+	return ""
+}
+
+// splitParagraphs merges blank-line separated paragraphs into chunks of about
+// targetChunkLines lines
+func splitParagraphs(filePath string, lines []string) []*Chunk {
+	markdown := path.Ext(filePath) == ".md"
+
+	var chunks []*Chunk
+	start, symbol := 0, ""
+	flush := func(end int) {
+		// Skip chunks made of blank lines only
+		for start < end && strings.TrimSpace(lines[start]) == "" {
+			start++
+		}
+		if start < end {
+			chunks = append(chunks, window(filePath, symbol, lines, start+1, end)...)
+		}
+		start = end
+	}
+
+	for i, line := range lines {
+		if markdown && strings.HasPrefix(line, "#") {
+			flush(i)
+			symbol = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+		if strings.TrimSpace(line) == "" && i-start >= targetChunkLines {
+			flush(i)
+		}
+	}
+	flush(len(lines))
+	return chunks
+}
+
+// window makes chunks of lines from..to (1-based, inclusive), splitting ranges
+// longer than maxChunkLines
+func window(filePath, symbol string, lines []string, from, to int) []*Chunk {
+	if to > len(lines) {
+		to = len(lines)
+	}
+
+	var chunks []*Chunk
+	for part := 1; from <= to; part++ {
+		end := from + maxChunkLines - 1
+		if end > to {
+			end = to
+		}
+		name := symbol
+		if part > 1 && symbol != "" {
+			name = fmt.Sprintf("%s (part %d)", symbol, part)
+		}
+		chunks = append(chunks, &Chunk{
+			Path:   filePath,
+			Start:  from,
+			End:    end,
+			Symbol: name,
+			Text:   strings.Join(lines[from-1:end], "\n"),
+		})
+		from = end + 1
+	}
+	return chunks
+}
@@ -0,0 +1,134 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	httplib "zero-workflow/src/pkg/http"
+)
+
+// embedBatchSize is how many chunks are sent in one embeddings request
+const embedBatchSize = 64
+
+// maxEmbedChars keeps every input well below the usual 8k token input limit
+const maxEmbedChars = 16000
+
+// Embedder computes embeddings with an OpenAI-compatible /embeddings endpoint
+type Embedder struct {
+	URL        string
+	Model      string
+	apiKey     string
+	httpClient *httplib.SecureHTTPClient
+}
+
+// NewEmbedder creates an embedder for the endpoint at baseURL, e.g.
+// "https://api.openai.com/v1"
+func NewEmbedder(baseURL, model, apiKey string) *Embedder {
+	return &Embedder{
+		URL:        strings.TrimRight(baseURL, "/") + "/embeddings",
+		Model:      model,
+		apiKey:     apiKey,
+		httpClient: httplib.NewSecureHTTPClient(60 * time.Second),
+	}
+}
+
+// Embed returns the embeddings of texts, in order
+func (e *Embedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var embeddings [][]float32
+	for start := 0; start < len(texts); start += embedBatchSize {
+		end := start + embedBatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := e.embedBatch(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
+
+func (e *Embedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	inputs := make([]string, len(texts))
+	for i, text := range texts {
+		if len(text) > maxEmbedChars {
+			text = text[:maxEmbedChars]
+		}
+		inputs[i] = text
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"model": e.Model,
+		"input": inputs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embeddings request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("embeddings request failed: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid embeddings response: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("invalid embeddings response: got %d embeddings for %d inputs", len(result.Data), len(texts))
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("invalid embeddings response: index %d out of range", item.Index)
+		}
+		embeddings[item.Index] = item.Embedding
+	}
+	return embeddings, nil
+}
+
+// cosine returns the cosine similarity of two vectors
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
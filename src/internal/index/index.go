@@ -0,0 +1,337 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"zero-workflow/src/internal/files"
+	"zero-workflow/src/internal/patch"
+	"zero-workflow/src/internal/redact"
+)
+
+const (
+	// Dir is where the index is stored, relative to the repository root
+	Dir = ".zw/index"
+
+	fileName      = "index.gob"
+	formatVersion = 1
+	maxFileSize   = 1024 * 1024 // larger files are not indexed
+
+	// rrfK damps the rank fusion of keyword and embedding results
+	rrfK = 60
+)
+
+// Index is a search index of the source files of a repository. It keeps the
+// chunks of every file with the hash of the content they were made from, so
+// that updating it only re-reads changed files.
+type Index struct {
+	Version        int
+	EmbeddingModel string // model of the chunk embeddings, "" without embeddings
+	Files          map[string]*File
+
+	root   string
+	chunks []*Chunk
+	stats  *bm25
+}
+
+// File is an indexed file
+type File struct {
+	Hash   string
+	Chunks []*Chunk
+}
+
+// Result is a chunk found by Search
+type Result struct {
+	Chunk *Chunk
+	Score float64
+}
+
+// UpdateStats tells what Update changed
+type UpdateStats struct {
+	Added     int
+	Updated   int
+	Removed   int
+	Unchanged int
+	Chunks    int
+	Embedded  int              // chunks whose embeddings were computed
+	Redacted  []redact.Finding // secrets removed from the chunks sent for embeddings
+}
+
+// New returns an empty index for the repository at root
+func New(root string) *Index {
+	return &Index{Version: formatVersion, Files: map[string]*File{}, root: root}
+}
+
+// Load reads the index of the repository at root. os.IsNotExist(err) is true
+// when there is no index yet.
+func Load(root string) (*Index, error) {
+	data, err := os.ReadFile(filepath.Join(root, Dir, fileName))
+	if err != nil {
+		return nil, err
+	}
+
+	ix := &Index{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(ix); err != nil {
+		return nil, fmt.Errorf("corrupt index, run zw index --rebuild: %w", err)
+	}
+	if ix.Version != formatVersion {
+		return nil, fmt.Errorf("index was built by another version of zw, run zw index --rebuild")
+	}
+	if ix.Files == nil {
+		ix.Files = map[string]*File{}
+	}
+	ix.root = root
+	ix.prepare()
+	return ix, nil
+}
+
+// Save writes the index under Dir. The directory gets its own .gitignore so
+// that the index is never committed.
+func (ix *Index) Save() error {
+	dir := filepath.Join(ix.root, Dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", Dir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*\n"), 0644); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(ix); err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, fileName+".tmp")
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, fileName))
+}
+
+// Update brings the index in line with paths (relative to the root). Files whose
+// content hash is unchanged keep their chunks; files no longer listed are
+// dropped. With an embedder, chunks without embeddings get them, with secrets
+// removed by redactor (if not nil) before they are sent; without one, embeddings
+// are removed so that the index stays consistent.
+func (ix *Index) Update(ctx context.Context, paths []string, embedder *Embedder, redactor *redact.Redactor) (*UpdateStats, error) {
+	stats := &UpdateStats{}
+	listed := map[string]bool{}
+
+	for _, path := range paths {
+		content, ok, err := ix.readSource(path)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		listed[path] = true
+
+		sum := sha256.Sum256([]byte(content))
+		hash := hex.EncodeToString(sum[:])
+		existing := ix.Files[path]
+		switch {
+		case existing != nil && existing.Hash == hash:
+			stats.Unchanged++
+			continue
+		case existing != nil:
+			stats.Updated++
+		default:
+			stats.Added++
+		}
+		ix.Files[path] = &File{Hash: hash, Chunks: Split(path, content)}
+	}
+
+	for path := range ix.Files {
+		if !listed[path] {
+			delete(ix.Files, path)
+			stats.Removed++
+		}
+	}
+
+	ix.prepare()
+	stats.Chunks = len(ix.chunks)
+
+	if embedder == nil || ix.EmbeddingModel != embedder.Model {
+		for _, chunk := range ix.chunks {
+			chunk.Embedding = nil
+		}
+		ix.EmbeddingModel = ""
+	}
+	if embedder != nil {
+		var missing []*Chunk
+		var texts []string
+		for _, chunk := range ix.chunks {
+			if chunk.Embedding == nil {
+				text := embeddingText(chunk)
+				if redactor != nil {
+					var findings []redact.Finding
+					text, findings = redactor.Redact(text)
+					stats.Redacted = append(stats.Redacted, findings...)
+				}
+				missing = append(missing, chunk)
+				texts = append(texts, text)
+			}
+		}
+		embeddings, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		for i, chunk := range missing {
+			chunk.Embedding = embeddings[i]
+		}
+		ix.EmbeddingModel = embedder.Model
+		stats.Embedded = len(missing)
+	}
+
+	return stats, nil
+}
+
+// readSource reads a file to index as UTF-8 text; ok is false for missing,
+// large and binary files
+func (ix *Index) readSource(path string) (string, bool, error) {
+	full := filepath.Join(ix.root, filepath.FromSlash(path))
+	info, err := os.Lstat(full)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxFileSize {
+		return "", false, nil
+	}
+	content, err := os.ReadFile(full)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	text, ok := files.DecodeText(content)
+	return text, ok, nil
+}
+
+// prepare flattens the chunks in path order and computes the term statistics
+func (ix *Index) prepare() {
+	paths := make([]string, 0, len(ix.Files))
+	for path := range ix.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	ix.chunks = ix.chunks[:0]
+	for _, path := range paths {
+		ix.chunks = append(ix.chunks, ix.Files[path].Chunks...)
+	}
+	ix.stats = newBM25(ix.chunks)
+}
+
+// Chunks returns the number of chunks in the index
+func (ix *Index) Chunks() int {
+	return len(ix.chunks)
+}
+
+// Search returns the k chunks that match query best. Chunks are ranked by
+// BM25; when the index has embeddings and queryEmbedding is given, the keyword
+// and embedding rankings are merged with reciprocal rank fusion.
+func (ix *Index) Search(query string, k int, queryEmbedding []float32) []Result {
+	scores := ix.stats.scores(query)
+	fused := make([]float64, len(ix.chunks))
+
+	addRanking := func(score func(i int) float64) {
+		var ranked []int
+		for i := range ix.chunks {
+			if score(i) > 0 {
+				ranked = append(ranked, i)
+			}
+		}
+		sort.SliceStable(ranked, func(a, b int) bool {
+			return score(ranked[a]) > score(ranked[b])
+		})
+		for rank, i := range ranked {
+			fused[i] += 1 / float64(rrfK+rank+1)
+		}
+	}
+
+	addRanking(func(i int) float64 { return scores[i] })
+	if queryEmbedding != nil && ix.EmbeddingModel != "" {
+		addRanking(func(i int) float64 { return cosine(queryEmbedding, ix.chunks[i].Embedding) })
+	}
+
+	var results []Result
+	for i, score := range fused {
+		if score > 0 {
+			results = append(results, Result{Chunk: ix.chunks[i], Score: score})
+		}
+	}
+	sort.SliceStable(results, func(a, b int) bool {
+		return results[a].Score > results[b].Score
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// embeddingText prefixes a chunk with its location, which helps the embedding
+// tell similar code in different places apart
+func embeddingText(chunk *Chunk) string {
+	header := chunk.Path
+	if chunk.Symbol != "" {
+		header += " " + chunk.Symbol
+	}
+	return header + "\n" + chunk.Text
+}
+
+// SourceFiles lists the files of the repository worth indexing: tracked files and
+// untracked files that are not ignored, without hidden, vendored, lock and
+// minified files and files the policy refuses, so that --rag never sends what
+// --file would not
+func SourceFiles(repo *git.Repository, policy *files.Policy) ([]string, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git status: %w", err)
+	}
+
+	var paths []string
+	for _, entry := range idx.Entries {
+		paths = append(paths, entry.Name)
+	}
+	for path, fileStatus := range status {
+		if fileStatus.Staging == git.Untracked {
+			paths = append(paths, path)
+		}
+	}
+
+	var sources []string
+	root := worktree.Filesystem.Root()
+	for _, path := range paths {
+		if patch.IsGenerated(path) || hidden(path) {
+			continue
+		}
+		if policy.Check(filepath.Join(root, filepath.FromSlash(path))) != nil {
+			continue
+		}
+		sources = append(sources, path)
+	}
+	sort.Strings(sources)
+	return sources, nil
+}
+
+// hidden reports whether any component of path starts with a dot
+func hidden(path string) bool {
+	for _, part := range strings.Split(path, "/") {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package patch
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// generatedFiles are lock files and similar whose diffs say little about a change
+var generatedFiles = map[string]bool{
+	"go.sum":            true,
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"Cargo.lock":        true,
+	"poetry.lock":       true,
+	"composer.lock":     true,
+	"Gemfile.lock":      true,
+}
+
+// Generated reports whether the file is a lock file, vendored or minified code
+func (f *File) Generated() bool {
+	return IsGenerated(f.Path())
+}
+
+// IsGenerated reports whether the slash-separated path p names a lock file,
+// vendored or minified code
+func IsGenerated(p string) bool {
+	base := path.Base(p)
+	return generatedFiles[base] ||
+		strings.HasPrefix(p, "vendor/") || strings.Contains(p, "/vendor/") ||
+		strings.HasPrefix(p, "node_modules/") || strings.Contains(p, "/node_modules/") ||
+		strings.HasSuffix(base, ".min.js") || strings.HasSuffix(base, ".min.css")
+}
+
+// Budget renders a diff in at most about limit bytes without cutting it blindly.
+// Small files are kept whole and the remaining space is shared evenly among the
+// larger ones, which are cut at hunk boundaries. Generated and binary files are
+// only listed. Files keep their original order.
+func Budget(files []*File, limit int) string {
+	rendered := make([]string, len(files))
+
+	var candidates []int
+	for i, file := range files {
+		switch {
+		case file.Binary:
+			rendered[i] = headerLine(file) + "(binary file changed)\n"
+		case file.Generated():
+			rendered[i] = headerLine(file) + fmt.Sprintf("(generated file, +%d -%d lines, diff omitted)\n", file.Added(), file.Removed())
+		default:
+			candidates = append(candidates, i)
+		}
+	}
+
+	remaining := limit
+	for _, text := range rendered {
+		remaining -= len(text)
+	}
+
+	// Water-filling: visit files from smallest to largest, giving each an equal share
+	// of what is left; files smaller than their share leave the rest to the others
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return len(files[candidates[a]].String()) < len(files[candidates[b]].String())
+	})
+	for n, i := range candidates {
+		share := 0
+		if remaining > 0 {
+			share = remaining / (len(candidates) - n)
+		}
+		rendered[i] = files[i].Truncate(share)
+		remaining -= len(rendered[i])
+	}
+
+	return strings.Join(rendered, "")
+}
+
+// Truncate renders the file with as many leading hunks as fit into limit bytes,
+// noting how many were left out. The header is always included.
+func (f *File) Truncate(limit int) string {
+	full := f.String()
+	if len(full) <= limit {
+		return full
+	}
+
+	var builder strings.Builder
+	for _, line := range f.Header {
+		builder.WriteString(line + "\n")
+	}
+
+	kept := 0
+	for _, hunk := range f.Hunks {
+		text := hunk.String()
+		if builder.Len()+len(text) > limit {
+			if kept == 0 {
+				// Show the beginning of a hunk too large to fit rather than nothing
+				builder.WriteString(hunk.Header + "\n")
+				for _, line := range hunk.Lines {
+					if builder.Len()+len(line)+1 > limit {
+						break
+					}
+					builder.WriteString(line + "\n")
+				}
+				builder.WriteString("... (hunk truncated)\n")
+				kept++
+			}
+			break
+		}
+		builder.WriteString(text)
+		kept++
+	}
+
+	if omitted := len(f.Hunks) - kept; omitted > 0 {
+		builder.WriteString(fmt.Sprintf("... (%d of %d hunks omitted, +%d -%d lines in total)\n", omitted, len(f.Hunks), f.Added(), f.Removed()))
+	}
+	return builder.String()
+}
+
+// Added returns the number of added lines in the file
+func (f *File) Added() int {
+	n := 0
+	for _, hunk := range f.Hunks {
+		n += hunk.Added()
+	}
+	return n
+}
+
+// Removed returns the number of removed lines in the file
+func (f *File) Removed() int {
+	n := 0
+	for _, hunk := range f.Hunks {
+		n += hunk.Removed()
+	}
+	return n
+}
+
+func headerLine(f *File) string {
+	if len(f.Header) > 0 {
+		return f.Header[0] + "\n"
+	}
+	return "diff --git a/" + f.Path() + " b/" + f.Path() + "\n"
+}
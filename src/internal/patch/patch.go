@@ -0,0 +1,165 @@
+package patch
+
+import (
+	"strings"
+)
+
+// File is the diff of a single file in "git diff" output
+type File struct {
+	OldPath string
+	NewPath string
+	Header  []string // "diff --git" line and extended headers up to the first hunk
+	Hunks   []*Hunk
+	New     bool
+	Deleted bool
+	Binary  bool
+	Mode    bool // the file mode changes
+}
+
+// Hunk is a single "@@ ... @@" section of a file diff
+type Hunk struct {
+	Header string   // the "@@ -a,b +c,d @@ context" line
+	Lines  []string // body lines including their " ", "+", "-" or "\" prefix
+}
+
+// Path returns the path of the file after the change, or before it for deletions
+func (f *File) Path() string {
+	if f.Deleted || f.NewPath == "" {
+		return f.OldPath
+	}
+	return f.NewPath
+}
+
+// Whole reports whether the file can only be staged as a unit rather than hunk by hunk
+func (f *File) Whole() bool {
+	return f.New || f.Deleted || f.Binary || f.Mode || len(f.Hunks) == 0
+}
+
+// String renders the file diff with all of its hunks
+func (f *File) String() string {
+	return f.Render(f.Hunks)
+}
+
+// Render renders the file header followed by the given subset of its hunks
+func (f *File) Render(hunks []*Hunk) string {
+	var builder strings.Builder
+	for _, line := range f.Header {
+		builder.WriteString(line)
+		builder.WriteString("\n")
+	}
+	for _, hunk := range hunks {
+		builder.WriteString(hunk.String())
+	}
+	return builder.String()
+}
+
+// String renders the hunk header and body
+func (h *Hunk) String() string {
+	var builder strings.Builder
+	builder.WriteString(h.Header)
+	builder.WriteString("\n")
+	for _, line := range h.Lines {
+		builder.WriteString(line)
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// Range returns the "@@ -a,b +c,d @@" part of the header without the trailing context
+func (h *Hunk) Range() string {
+	if end := strings.Index(h.Header[2:], "@@"); end >= 0 {
+		return h.Header[:end+4]
+	}
+	return h.Header
+}
+
+// Added returns the number of added lines in the hunk
+func (h *Hunk) Added() int {
+	return h.count('+')
+}
+
+// Removed returns the number of removed lines in the hunk
+func (h *Hunk) Removed() int {
+	return h.count('-')
+}
+
+func (h *Hunk) count(prefix byte) int {
+	n := 0
+	for _, line := range h.Lines {
+		if len(line) > 0 && line[0] == prefix {
+			n++
+		}
+	}
+	return n
+}
+
+// Parse splits "git diff" output into per-file diffs
+func Parse(diff string) []*File {
+	var files []*File
+	var file *File
+	var hunk *Hunk
+
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file = &File{Header: []string{line}}
+			file.OldPath, file.NewPath = parseDiffGitPaths(line)
+			files = append(files, file)
+			hunk = nil
+		case file == nil:
+			continue
+		case strings.HasPrefix(line, "@@"):
+			hunk = &Hunk{Header: line}
+			file.Hunks = append(file.Hunks, hunk)
+		case hunk != nil:
+			hunk.Lines = append(hunk.Lines, line)
+		default:
+			file.Header = append(file.Header, line)
+			switch {
+			case strings.HasPrefix(line, "new file mode"):
+				file.New = true
+			case strings.HasPrefix(line, "deleted file mode"):
+				file.Deleted = true
+			case strings.HasPrefix(line, "old mode "):
+				file.Mode = true
+			case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+				file.Binary = true
+			case strings.HasPrefix(line, "--- "):
+				if path := stripPrefix(line[4:]); path != "" {
+					file.OldPath = path
+				}
+			case strings.HasPrefix(line, "+++ "):
+				if path := stripPrefix(line[4:]); path != "" {
+					file.NewPath = path
+				}
+			}
+		}
+	}
+
+	return files
+}
+
+// parseDiffGitPaths extracts paths from "diff --git a/x b/x"; ambiguous names are
+// corrected later by the "---" and "+++" lines when present
+func parseDiffGitPaths(line string) (string, string) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if strings.HasPrefix(rest, "a/") {
+		if i := strings.Index(rest, " b/"); i >= 0 {
+			return rest[2:i], rest[i+3:]
+		}
+	}
+	return "", ""
+}
+
+// stripPrefix removes the a/ or b/ prefix; /dev/null yields an empty path
+func stripPrefix(path string) string {
+	path = strings.TrimSuffix(strings.TrimSpace(path), "\t")
+	path = strings.Trim(path, `"`)
+	if path == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		return path[2:]
+	}
+	return path
+}
@@ -0,0 +1,237 @@
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"zero-workflow/src/internal/config"
+)
+
+// Rule is a built-in secret detector. Group selects the submatch that holds the
+// secret; 0 redacts the whole match. Secrets matching Skip are not redacted.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Group   int
+	Skip    *regexp.Regexp
+}
+
+// HighEntropy is the name of the detector for random-looking strings
+const HighEntropy = "high-entropy"
+
+// sensitiveName matches names of settings that usually hold secrets
+const sensitiveName = `[A-Za-z0-9_.-]*(?i:secret|token|passw(?:or)?d|pwd|api[_-]?key|access[_-]?key|private[_-]?key|auth|credential|dsn|database[_-]?url|connection[_-]?string)[A-Za-z0-9_.-]*`
+
+// Rules are the built-in detectors, most specific first
+var Rules = []Rule{
+	{Name: "private-key", Pattern: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+	{Name: "aws-access-key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`)},
+	{Name: "aws-secret-key", Pattern: regexp.MustCompile(`(?i)aws.{0,20}(?:secret|private).{0,20}?['"]?\s*[:=]\s*['"]?([A-Za-z0-9/+=]{40})\b`), Group: 1},
+	{Name: "github-token", Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	{Name: "gitlab-token", Pattern: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{Name: "slack-token", Pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{Name: "stripe-key", Pattern: regexp.MustCompile(`\b[sr]k_(?:live|test)_[A-Za-z0-9]{20,}\b`)},
+	{Name: "google-api-key", Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{Name: "openai-key", Pattern: regexp.MustCompile(`\bsk-(?:proj-|ant-)?[A-Za-z0-9_-]{32,}\b`)},
+	{Name: "jwt", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{Name: "url-credentials", Pattern: regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@]+:([^\s:/@]{3,})@`), Group: 1},
+	// KEY=value in env files and shell scripts, key: value in YAML, also on the
+	// added and removed lines of a diff. Values with code punctuation are
+	// expressions, and $NAME is a reference to a variable.
+	{
+		Name:    "secret-assignment",
+		Pattern: regexp.MustCompile(`(?m)^[+ -]?[ \t]*(?:export[ \t]+)?["']?` + sensitiveName + `["']?[ \t]*[:=][ \t]*["']?([^\s"'#(){}\[\];,<>]{6,})(?:["'],?)?[ \t]*(?:#.*)?$`),
+		Group:   1,
+		Skip:    regexp.MustCompile(`^\$|^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)+$`),
+	},
+}
+
+// entropyCandidate matches strings that may be random tokens
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_-]{24,}={0,2}`)
+
+// hexLike matches hashes and UUIDs, which are random but not secret
+var hexLike = regexp.MustCompile(`^[0-9a-fA-F-]+$`)
+
+// Finding is a redacted secret. Preview shows only its first characters.
+type Finding struct {
+	Rule    string
+	Preview string
+}
+
+// Redactor removes secrets from text
+type Redactor struct {
+	rules   []Rule
+	allow   []*regexp.Regexp
+	entropy bool
+	enabled bool
+}
+
+// New creates a redactor from the [redact] settings of the repository
+func New(cfg config.RedactConfig) (*Redactor, error) {
+	r := &Redactor{allow: cfg.Allow, entropy: true, enabled: cfg.Enabled}
+
+	disabled := map[string]bool{}
+	for _, name := range cfg.Disable {
+		if name != HighEntropy && !knownRule(name) {
+			return nil, fmt.Errorf("unknown redact rule %q", name)
+		}
+		disabled[name] = true
+	}
+	r.entropy = !disabled[HighEntropy]
+
+	for _, rule := range Rules {
+		if !disabled[rule.Name] {
+			r.rules = append(r.rules, rule)
+		}
+	}
+	for i, pattern := range cfg.Deny {
+		r.rules = append(r.rules, Rule{Name: fmt.Sprintf("deny-%d", i+1), Pattern: pattern})
+	}
+	return r, nil
+}
+
+func knownRule(name string) bool {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// span is a part of the text to redact
+type span struct {
+	start, end int
+	rule       string
+}
+
+// Redact replaces every secret in text with "[REDACTED:<rule>]" and returns the
+// findings. Matches of an allow pattern are kept.
+func (r *Redactor) Redact(text string) (string, []Finding) {
+	if !r.enabled {
+		return text, nil
+	}
+
+	var spans []span
+	for _, rule := range r.rules {
+		for _, match := range rule.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := match[0], match[1]
+			if rule.Group > 0 && len(match) > 2*rule.Group+1 && match[2*rule.Group] >= 0 {
+				start, end = match[2*rule.Group], match[2*rule.Group+1]
+			}
+			if rule.Skip != nil && rule.Skip.MatchString(text[start:end]) {
+				continue
+			}
+			spans = append(spans, span{start, end, rule.Name})
+		}
+	}
+	if r.entropy {
+		// The entropy check is a fallback for what no specific rule recognised
+		matched := len(spans)
+		for _, match := range entropyCandidate.FindAllStringIndex(text, -1) {
+			if looksRandom(text[match[0]:match[1]]) && !overlaps(spans[:matched], match[0], match[1]) {
+				spans = append(spans, span{match[0], match[1], HighEntropy})
+			}
+		}
+	}
+
+	// Earlier spans win; among spans starting at the same place, the longest
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+
+	var builder strings.Builder
+	var findings []Finding
+	last := 0
+	for _, s := range spans {
+		if s.start < last || r.allowed(text[s.start:s.end]) {
+			continue
+		}
+		builder.WriteString(text[last:s.start])
+		builder.WriteString("[REDACTED:" + s.rule + "]")
+		findings = append(findings, Finding{Rule: s.rule, Preview: preview(text[s.start:s.end])})
+		last = s.end
+	}
+	if findings == nil {
+		return text, nil
+	}
+	builder.WriteString(text[last:])
+	return builder.String(), findings
+}
+
+// overlaps reports whether start:end overlaps any of spans
+func overlaps(spans []span, start, end int) bool {
+	for _, s := range spans {
+		if s.start < end && start < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) allowed(secret string) bool {
+	for _, pattern := range r.allow {
+		if pattern.MatchString(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksRandom reports whether s looks like a generated token: long, mixing
+// letters and digits, and with a high Shannon entropy
+func looksRandom(s string) bool {
+	if hexLike.MatchString(s) || strings.Count(s, "/") > 2 {
+		return false
+	}
+
+	var upper, lower, digit bool
+	for _, c := range s {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	if !digit || !(upper && lower) {
+		return false
+	}
+	return entropy(s) >= 4.3
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, c := range s {
+		counts[c]++
+	}
+	var result float64
+	n := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / n
+		result -= p * math.Log2(p)
+	}
+	return result
+}
+
+// preview shows the start of a secret, enough to recognize it but not to use it
+func preview(secret string) string {
+	if strings.HasPrefix(secret, "-----BEGIN") {
+		line, _, _ := strings.Cut(secret, "\n")
+		return line
+	}
+	runes := []rune(secret)
+	if len(runes) <= 8 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:4]) + "…"
+}
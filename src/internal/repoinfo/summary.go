@@ -0,0 +1,221 @@
+package repoinfo
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"zero-workflow/src/internal/gitutil"
+	"zero-workflow/src/internal/patch"
+)
+
+const (
+	recentCommits = 10
+	maxTreeLines  = 300
+	maxDiffSize   = 12000 // bytes for each of the staged and unstaged diffs
+)
+
+// Summary is a compact description of a repository's state, used as context
+// for questions about the project as a whole
+type Summary struct {
+	Name     string   // name of the worktree directory
+	Branch   string   // current branch, or "detached at <hash>"
+	Commits  []string // recent commits, newest first
+	Status   []string // "git status --short" style lines
+	Files    []string // tracked and untracked files that are not ignored
+	Staged   string   // staged diff, budgeted
+	Unstaged string   // unstaged diff, budgeted
+}
+
+// Summarize collects the summary of repo with go-git
+func Summarize(repo *git.Repository) (*Summary, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	summary := &Summary{Name: filepath.Base(worktree.Filesystem.Root())}
+
+	head, err := repo.Head()
+	switch {
+	case err == plumbing.ErrReferenceNotFound:
+		summary.Branch = "no commits yet"
+	case err != nil:
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	case head.Name().IsBranch():
+		summary.Branch = head.Name().Short()
+	default:
+		summary.Branch = "detached at " + head.Hash().String()[:7]
+	}
+
+	if head != nil {
+		if summary.Commits, err = recentLog(repo, head.Hash()); err != nil {
+			return nil, err
+		}
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git status: %w", err)
+	}
+	for path, fileStatus := range status {
+		if fileStatus.Staging == git.Unmodified && fileStatus.Worktree == git.Unmodified {
+			continue
+		}
+		summary.Status = append(summary.Status, fmt.Sprintf("%c%c %s", fileStatus.Staging, fileStatus.Worktree, path))
+		if fileStatus.Staging == git.Untracked {
+			summary.Files = append(summary.Files, path)
+		}
+	}
+	sort.Slice(summary.Status, func(i, j int) bool {
+		return summary.Status[i][3:] < summary.Status[j][3:]
+	})
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	for _, entry := range idx.Entries {
+		summary.Files = append(summary.Files, entry.Name)
+	}
+	sort.Strings(summary.Files)
+
+	staged, unstaged, err := gitutil.WorktreeDiff(repo, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff worktree: %w", err)
+	}
+	summary.Staged = patch.Budget(patch.Parse(staged), maxDiffSize)
+	summary.Unstaged = patch.Budget(patch.Parse(unstaged), maxDiffSize)
+
+	return summary, nil
+}
+
+// recentLog returns the latest commits as "hash date subject" lines
+func recentLog(repo *git.Repository, from plumbing.Hash) ([]string, error) {
+	commits, err := repo.Log(&git.LogOptions{From: from})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log: %w", err)
+	}
+	defer commits.Close()
+
+	var lines []string
+	for len(lines) < recentCommits {
+		commit, err := commits.Next()
+		if err != nil {
+			break
+		}
+		lines = append(lines, formatCommit(commit))
+	}
+	return lines, nil
+}
+
+func formatCommit(commit *object.Commit) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+	return fmt.Sprintf("%s %s %s", commit.Hash.String()[:7], commit.Author.When.Format("2006-01-02"), subject)
+}
+
+// Format renders the summary as plain text
+func (s *Summary) Format() string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("Branch: %s\n", s.Branch))
+
+	if len(s.Commits) > 0 {
+		builder.WriteString("\nRecent commits:\n")
+		for _, commit := range s.Commits {
+			builder.WriteString("  " + commit + "\n")
+		}
+	}
+
+	builder.WriteString("\nStatus:\n")
+	if len(s.Status) == 0 {
+		builder.WriteString("  clean\n")
+	}
+	for _, line := range s.Status {
+		builder.WriteString("  " + line + "\n")
+	}
+
+	builder.WriteString(fmt.Sprintf("\nFiles (%d):\n", len(s.Files)))
+	builder.WriteString(formatTree(s.Files, maxTreeLines))
+
+	if s.Staged != "" {
+		builder.WriteString("\nStaged changes:\n" + strings.TrimRight(s.Staged, "\n") + "\n")
+	}
+	if s.Unstaged != "" {
+		builder.WriteString("\nUnstaged changes:\n" + strings.TrimRight(s.Unstaged, "\n") + "\n")
+	}
+
+	return builder.String()
+}
+
+// treeNode is a directory in the file tree
+type treeNode struct {
+	dirs  map[string]*treeNode
+	files []string
+	count int // files below this directory
+}
+
+// formatTree renders paths as an indented tree of at most about limit lines.
+// Directories are collapsed to "dir/ (N files)" from the deepest level up
+// until the tree fits.
+func formatTree(paths []string, limit int) string {
+	root := &treeNode{dirs: map[string]*treeNode{}}
+	maxDepth := 0
+	for _, path := range paths {
+		parts := strings.Split(path, "/")
+		if len(parts) > maxDepth {
+			maxDepth = len(parts)
+		}
+		node := root
+		node.count++
+		for _, dir := range parts[:len(parts)-1] {
+			child := node.dirs[dir]
+			if child == nil {
+				child = &treeNode{dirs: map[string]*treeNode{}}
+				node.dirs[dir] = child
+			}
+			child.count++
+			node = child
+		}
+		node.files = append(node.files, parts[len(parts)-1])
+	}
+
+	var lines []string
+	for depth := maxDepth; depth >= 1; depth-- {
+		lines = root.render("  ", depth, nil)
+		if len(lines) <= limit {
+			break
+		}
+	}
+	if len(lines) > limit {
+		lines = append(lines[:limit], fmt.Sprintf("  ... and %d more", len(lines)-limit))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// render lists the directories and files of node; directories below depth are
+// collapsed
+func (n *treeNode) render(indent string, depth int, lines []string) []string {
+	names := make([]string, 0, len(n.dirs))
+	for name := range n.dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		dir := n.dirs[name]
+		if depth <= 1 {
+			lines = append(lines, fmt.Sprintf("%s%s/ (%d files)", indent, name, dir.count))
+			continue
+		}
+		lines = append(lines, indent+name+"/")
+		lines = dir.render(indent+"  ", depth-1, lines)
+	}
+	for _, file := range n.files {
+		lines = append(lines, indent+file)
+	}
+	return lines
+}
@@ -0,0 +1,182 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severities, from most to least serious
+const (
+	Error   = "error"
+	Warning = "warning"
+	Info    = "info"
+)
+
+// Severities lists every severity, most serious first
+var Severities = []string{Error, Warning, Info}
+
+// Categories a finding can be filed under; anything else becomes "maintainability"
+var Categories = []string{"bug", "security", "performance", "maintainability", "style", "test"}
+
+// Finding is a single review comment attached to lines of a changed file
+type Finding struct {
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	EndLine    int    `json:"end_line,omitempty"`
+	Severity   string `json:"severity"`
+	Category   string `json:"category"`
+	Title      string `json:"title"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// Report is the result of a review
+type Report struct {
+	Target   string    `json:"target"` // "staged changes" or "<base>...HEAD"
+	Summary  string    `json:"summary"`
+	Findings []Finding `json:"findings"`
+}
+
+// Parse reads the JSON object the model was asked to answer with. Code fences and
+// text around the object are ignored.
+func Parse(response string) (*Report, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object in response")
+	}
+
+	report := &Report{}
+	if err := json.Unmarshal([]byte(response[start:end+1]), report); err != nil {
+		return nil, fmt.Errorf("invalid review JSON: %w", err)
+	}
+	if report.Findings == nil {
+		report.Findings = []Finding{}
+	}
+	return report, nil
+}
+
+// Normalize drops findings for files that are not part of the change, fixes up
+// severities, categories and line ranges, and sorts the findings by file and line.
+// It returns the number of dropped findings.
+func (r *Report) Normalize(paths []string) int {
+	changed := map[string]bool{}
+	for _, path := range paths {
+		changed[path] = true
+	}
+
+	kept := []Finding{}
+	for _, finding := range r.Findings {
+		finding.File = strings.TrimPrefix(strings.TrimPrefix(finding.File, "b/"), "./")
+		if !changed[finding.File] || strings.TrimSpace(finding.Message+finding.Title) == "" {
+			continue
+		}
+
+		finding.Severity = strings.ToLower(strings.TrimSpace(finding.Severity))
+		if severityRank(finding.Severity) == len(Severities) {
+			finding.Severity = Warning
+		}
+		finding.Category = strings.ToLower(strings.TrimSpace(finding.Category))
+		if !contains(Categories, finding.Category) {
+			finding.Category = "maintainability"
+		}
+		if finding.Line < 1 {
+			finding.Line = 1
+		}
+		if finding.EndLine <= finding.Line {
+			finding.EndLine = 0
+		}
+		kept = append(kept, finding)
+	}
+
+	dropped := len(r.Findings) - len(kept)
+	sort.SliceStable(kept, func(a, b int) bool {
+		if kept[a].File != kept[b].File {
+			return kept[a].File < kept[b].File
+		}
+		return kept[a].Line < kept[b].Line
+	})
+	r.Findings = kept
+	return dropped
+}
+
+// Count returns the number of findings with the given severity
+func (r *Report) Count(severity string) int {
+	n := 0
+	for _, finding := range r.Findings {
+		if finding.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Markdown renders the report grouped by file
+func (r *Report) Markdown() string {
+	var builder strings.Builder
+	builder.WriteString("## Review of " + r.Target + "\n\n")
+	if r.Summary != "" {
+		builder.WriteString(strings.TrimSpace(r.Summary) + "\n\n")
+	}
+
+	if len(r.Findings) == 0 {
+		builder.WriteString("No issues found.\n")
+		return builder.String()
+	}
+
+	file := ""
+	for _, finding := range r.Findings {
+		if finding.File != file {
+			file = finding.File
+			builder.WriteString("### " + file + "\n\n")
+		}
+
+		builder.WriteString(fmt.Sprintf("- **%s** %s (%s): **%s**\n", strings.ToUpper(finding.Severity), finding.Lines(), finding.Category, strings.TrimSpace(finding.Title)))
+		if message := strings.TrimSpace(finding.Message); message != "" {
+			builder.WriteString("  " + strings.ReplaceAll(message, "\n", "\n  ") + "\n")
+		}
+		if suggestion := strings.TrimSpace(finding.Suggestion); suggestion != "" {
+			builder.WriteString("  Suggestion: " + strings.ReplaceAll(suggestion, "\n", "\n  ") + "\n")
+		}
+		builder.WriteString("\n")
+	}
+
+	var counts []string
+	for _, severity := range Severities {
+		if n := r.Count(severity); n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, severity))
+		}
+	}
+	builder.WriteString(fmt.Sprintf("%d finding(s): %s\n", len(r.Findings), strings.Join(counts, ", ")))
+	return builder.String()
+}
+
+// Lines formats the line range as "line 12" or "lines 12-18"
+func (f Finding) Lines() string {
+	if f.EndLine > f.Line {
+		return fmt.Sprintf("lines %d-%d", f.Line, f.EndLine)
+	}
+	return fmt.Sprintf("line %d", f.Line)
+}
+
+// severityRank returns the position of severity in Severities, or len(Severities)
+// for unknown values
+func severityRank(severity string) int {
+	for i, known := range Severities {
+		if severity == known {
+			return i
+		}
+	}
+	return len(Severities)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package review
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// SARIF 2.1.0 subset understood by GitHub code scanning and editor SARIF viewers
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolURI      = "https://github.com/zeroworkflow/zw"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           sarifRegion   `json:"region"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// SARIF encodes the report as a SARIF log with one rule per category
+func (r *Report) SARIF(toolVersion string) ([]byte, error) {
+	driver := sarifDriver{Name: "zw", Version: toolVersion, InformationURI: toolURI, Rules: []sarifRule{}}
+	for _, category := range Categories {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:               "zw/" + category,
+			ShortDescription: sarifMessage{Text: "AI review: " + category},
+		})
+	}
+
+	results := []sarifResult{}
+	for _, finding := range r.Findings {
+		text := strings.TrimSpace(finding.Title)
+		if message := strings.TrimSpace(finding.Message); message != "" {
+			text += "\n\n" + message
+		}
+		if suggestion := strings.TrimSpace(finding.Suggestion); suggestion != "" {
+			text += "\n\nSuggestion: " + suggestion
+		}
+
+		results = append(results, sarifResult{
+			RuleID:  "zw/" + finding.Category,
+			Level:   sarifLevel(finding.Severity),
+			Message: sarifMessage{Text: strings.TrimSpace(text)},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifact{URI: finding.File},
+					Region:           sarifRegion{StartLine: finding.Line, EndLine: finding.EndLine},
+				},
+			}},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+	return json.MarshalIndent(log, "", "  ")
+}
+
+// sarifLevel maps severities to SARIF levels
+func sarifLevel(severity string) string {
+	if severity == Info {
+		return "note"
+	}
+	return severity
+}
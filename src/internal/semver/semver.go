@@ -0,0 +1,159 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"zero-workflow/src/internal/commitmsg"
+)
+
+// Level is the size of a version bump
+type Level int
+
+const (
+	None Level = iota
+	Patch
+	Minor
+	Major
+)
+
+// String returns the lower-case level name used in output
+func (l Level) String() string {
+	switch l {
+	case Patch:
+		return "patch"
+	case Minor:
+		return "minor"
+	case Major:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// ParseLevel parses "major", "minor", "patch" or "none"
+func ParseLevel(name string) (Level, bool) {
+	for _, level := range []Level{None, Patch, Minor, Major} {
+		if level.String() == name {
+			return level, true
+		}
+	}
+	return None, false
+}
+
+// versionPattern matches tags such as "v1.2.3", "1.2.3" and "v2.0.0-rc.1+build.5"
+var versionPattern = regexp.MustCompile(`^([vV]?)(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z.-]+))?(?:\+([0-9A-Za-z.-]+))?$`)
+
+// Version is a semantic version as found in a tag
+type Version struct {
+	Prefix     string // "v" or "", kept so the next tag looks like the last one
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	Build      string
+}
+
+// Parse parses a tag name. ok is false when it is not a semantic version.
+func Parse(tag string) (Version, bool) {
+	parts := versionPattern.FindStringSubmatch(tag)
+	if parts == nil {
+		return Version{}, false
+	}
+
+	var numbers [3]int
+	for i := range numbers {
+		n, err := strconv.Atoi(parts[i+2])
+		if err != nil {
+			return Version{}, false
+		}
+		numbers[i] = n
+	}
+
+	return Version{
+		Prefix:     parts[1],
+		Major:      numbers[0],
+		Minor:      numbers[1],
+		Patch:      numbers[2],
+		Prerelease: parts[5],
+		Build:      parts[6],
+	}, true
+}
+
+// String formats the version with its prefix
+func (v Version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Bump returns the next release version
+func (v Version) Bump(level Level) Version {
+	next := Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	switch level {
+	case Major:
+		next.Major, next.Minor, next.Patch = v.Major+1, 0, 0
+	case Minor:
+		next.Minor, next.Patch = v.Minor+1, 0
+	case Patch:
+		next.Patch = v.Patch + 1
+	}
+	return next
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or higher than other.
+// Prerelease versions sort before the release and are compared as plain strings;
+// build metadata is ignored.
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	case v.Prerelease < other.Prerelease:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// CommitLevel applies the Conventional Commits rules to a commit message: breaking
+// changes are major, features minor, fixes and other code changes patch, and
+// documentation or tooling changes none. ok is false for messages that do not
+// follow Conventional Commits.
+func CommitLevel(message string) (Level, bool) {
+	commit, ok := commitmsg.ParseConventional(message)
+	if !ok {
+		return None, false
+	}
+
+	switch {
+	case commit.Breaking:
+		return Major, true
+	case commit.Type == "feat":
+		return Minor, true
+	}
+
+	switch commit.Type {
+	case "docs", "style", "test", "tests", "chore", "ci", "build", "release", "wip":
+		return None, true
+	}
+	return Patch, true
+}
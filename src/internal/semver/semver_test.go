@@ -0,0 +1,112 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want Version
+		ok   bool
+	}{
+		{tag: "1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}, ok: true},
+		{tag: "v1.2.3", want: Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3}, ok: true},
+		{tag: "V0.1.0", want: Version{Prefix: "V", Minor: 1}, ok: true},
+		{tag: "v2.0.0-rc.1+build.5", want: Version{Prefix: "v", Major: 2, Prerelease: "rc.1", Build: "build.5"}, ok: true},
+		{tag: "v1.2"},
+		{tag: "v01.2.3"},
+		{tag: "release-1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, ok := Parse(tt.tag)
+			if ok != tt.ok || got != tt.want {
+				t.Fatalf("Parse(%q) = %+v, %v, want %+v, %v", tt.tag, got, ok, tt.want, tt.ok)
+			}
+			if ok && got.String() != tt.tag {
+				t.Errorf("String() = %q, want %q", got.String(), tt.tag)
+			}
+		})
+	}
+}
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		level   Level
+		want    string
+	}{
+		{name: "fix before 1.0", version: "v0.3.4", level: Patch, want: "v0.3.5"},
+		{name: "feat before 1.0", version: "v0.3.4", level: Minor, want: "v0.4.0"},
+		{name: "breaking before 1.0", version: "v0.3.4", level: Major, want: "v1.0.0"},
+		{name: "fix after 1.0", version: "1.2.3", level: Patch, want: "1.2.4"},
+		{name: "feat after 1.0", version: "1.2.3", level: Minor, want: "1.3.0"},
+		{name: "breaking after 1.0", version: "1.2.3", level: Major, want: "2.0.0"},
+		{name: "none", version: "v1.2.3", level: None, want: "v1.2.3"},
+		{name: "prerelease is released", version: "v2.0.0-rc.1+build.5", level: Patch, want: "v2.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, ok := Parse(tt.version)
+			if !ok {
+				t.Fatalf("Parse(%q) failed", tt.version)
+			}
+			if got := version.Bump(tt.level).String(); got != tt.want {
+				t.Errorf("Bump(%s) = %s, want %s", tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "v1.2.3", b: "1.2.3", want: 0},
+		{a: "1.2.3", b: "1.10.0", want: -1},
+		{a: "2.0.0", b: "1.9.9", want: 1},
+		{a: "1.0.0-rc.1", b: "1.0.0", want: -1},
+		{a: "1.0.0", b: "1.0.0-rc.1", want: 1},
+		{a: "1.0.0-alpha", b: "1.0.0-beta", want: -1},
+		{a: "1.0.0+build.1", b: "1.0.0+build.2", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, _ := Parse(tt.a)
+			b, _ := Parse(tt.b)
+			if got := a.Compare(b); got != tt.want {
+				t.Errorf("Compare = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitLevel(t *testing.T) {
+	tests := []struct {
+		message string
+		want    Level
+		ok      bool
+	}{
+		{message: "fix: handle empty tags", want: Patch, ok: true},
+		{message: "feat(version): add --json", want: Minor, ok: true},
+		{message: "feat!: drop the old config format", want: Major, ok: true},
+		{message: "fix: rename flag\n\nBREAKING CHANGE: --tag is now --next", want: Major, ok: true},
+		{message: "refactor: split bump logic", want: Patch, ok: true},
+		{message: "docs: describe version", want: None, ok: true},
+		{message: "chore(deps): update modules", want: None, ok: true},
+		{message: "Update README", want: None, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			got, ok := CommitLevel(tt.message)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("CommitLevel = %s, %v, want %s, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
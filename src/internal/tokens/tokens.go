@@ -0,0 +1,138 @@
+package tokens
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Model describes how much text a model can take
+type Model struct {
+	Name          string
+	ContextWindow int     // tokens the model accepts, prompt and answer together
+	Factor        float64 // tokenizer density relative to the estimate
+	Vision        bool    // the model accepts images
+}
+
+// knownModels maps name fragments to context windows and image support, most
+// specific first. Names are matched case-insensitively against the configured model.
+var knownModels = []Model{
+	{Name: "gpt-4o", ContextWindow: 128000, Factor: 1.0, Vision: true},
+	{Name: "gpt-4.1", ContextWindow: 1000000, Factor: 1.0, Vision: true},
+	{Name: "gpt-4-turbo", ContextWindow: 128000, Factor: 1.0, Vision: true},
+	{Name: "gpt-4", ContextWindow: 8192, Factor: 1.0},
+	{Name: "gpt-3.5", ContextWindow: 16385, Factor: 1.0},
+	{Name: "o1", ContextWindow: 128000, Factor: 1.0, Vision: true},
+	{Name: "o3", ContextWindow: 200000, Factor: 1.0, Vision: true},
+	{Name: "claude", ContextWindow: 200000, Factor: 1.15, Vision: true},
+	{Name: "gemini", ContextWindow: 1000000, Factor: 1.0, Vision: true},
+	{Name: "glm-4.5v", ContextWindow: 64000, Factor: 1.0, Vision: true},
+	{Name: "glm-4.5", ContextWindow: 128000, Factor: 1.0},
+	{Name: "glm-4v", ContextWindow: 8192, Factor: 1.0, Vision: true},
+	{Name: "glm-4", ContextWindow: 128000, Factor: 1.0},
+	{Name: "0727-360b-api", ContextWindow: 128000, Factor: 1.0}, // z.ai default (GLM-4.5)
+	{Name: "deepseek", ContextWindow: 64000, Factor: 1.0},
+	{Name: "qwen-vl", ContextWindow: 32768, Factor: 1.05, Vision: true},
+	{Name: "qwen", ContextWindow: 32768, Factor: 1.05},
+	{Name: "mistral", ContextWindow: 32000, Factor: 1.1},
+	{Name: "llava", ContextWindow: 4096, Factor: 1.1, Vision: true},
+	{Name: "llama", ContextWindow: 8192, Factor: 1.1},
+}
+
+// defaultModel is used for models that are not in the table
+var defaultModel = Model{Name: "default", ContextWindow: 32000, Factor: 1.1}
+
+// zaiModel is assumed for unknown models served by z.ai
+var zaiModel = Model{Name: "z.ai", ContextWindow: 128000, Factor: 1.0}
+
+// ForModel returns the limits of a model. ZW_CONTEXT_WINDOW overrides the
+// context window and ZW_VISION whether images are accepted, e.g. for models of
+// custom providers that are not known here.
+func ForModel(provider, name string) Model {
+	model := defaultModel
+	if provider == "zai" {
+		model = zaiModel
+	}
+	lower := strings.ToLower(name)
+	for _, known := range knownModels {
+		if strings.Contains(lower, known.Name) {
+			model = known
+			break
+		}
+	}
+	model.Name = name
+
+	if raw := os.Getenv("ZW_CONTEXT_WINDOW"); raw != "" {
+		if window, err := strconv.Atoi(raw); err == nil && window > 0 {
+			model.ContextWindow = window
+		}
+	}
+	if raw := os.Getenv("ZW_VISION"); raw != "" {
+		if vision, err := strconv.ParseBool(raw); err == nil {
+			model.Vision = vision
+		}
+	}
+	return model
+}
+
+// Budget returns the tokens left for attachments once the answer, the question
+// and some room for the system prompt and formatting are accounted for
+func (m Model) Budget(question string, answerTokens int) int {
+	budget := m.ContextWindow - answerTokens - m.Estimate(question) - 500
+	if budget < 0 {
+		return 0
+	}
+	return budget
+}
+
+// Estimate approximates the number of tokens in text without the model's
+// tokenizer. It follows how BPE tokenizers split text: short ASCII words are
+// one token and longer ones about one per four characters, punctuation is
+// mostly a token of its own, and non-Latin scripts take more tokens per character.
+func (m Model) Estimate(text string) int {
+	var tokens float64
+	word := 0
+	flush := func() {
+		if word > 0 {
+			tokens += math.Ceil(float64(word) / 4)
+			word = 0
+		}
+	}
+
+	spaces := 0
+	for _, r := range text {
+		switch {
+		case r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word++
+			spaces = 0
+			continue
+		case r == ' ' || r == '\t':
+			flush()
+			// Runs of indentation are merged into a few tokens
+			spaces++
+			if spaces%4 == 0 {
+				tokens++
+			}
+			continue
+		}
+
+		flush()
+		spaces = 0
+		switch {
+		case r == '\n':
+			tokens++
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			tokens += 1.2
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			// Cyrillic and other alphabets: roughly two to three characters per token
+			tokens += 0.4
+		default:
+			tokens++
+		}
+	}
+	flush()
+
+	return int(math.Ceil(tokens * m.Factor))
+}
@@ -67,6 +67,12 @@ func (c *Client) Chat(ctx context.Context, message string) (string, error) {
 
 // ChatStream implements the client interface
 func (c *Client) ChatStream(ctx context.Context, message string, callback types.StreamCallback) (string, error) {
+	return c.ChatStreamParts(ctx, []types.Part{types.TextPart(message)}, callback)
+}
+
+// ChatStreamParts sends a user message made of parts, such as a question with
+// screenshots, and streams the response. The model must accept images.
+func (c *Client) ChatStreamParts(ctx context.Context, parts []types.Part, callback types.StreamCallback) (string, error) {
 	systemPrompt := types.Message{
 		Role: "system",
 		Content: `Ты ZeroWorkflow AI - помощник разработчика. 
@@ -75,10 +81,7 @@ func (c *Client) ChatStream(ctx context.Context, message string, callback types.
 Для блоков кода используй тройные бэктики с указанием языка: ` + "```язык\nкод\n```",
 	}
 
-	userMessage := types.Message{
-		Role:    "user",
-		Content: message,
-	}
+	userMessage := types.NewMessage("user", parts...)
 
 	messages := []types.Message{systemPrompt, userMessage}
 	return c.ChatStreamWithMessages(ctx, messages, callback)
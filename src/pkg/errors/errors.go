@@ -200,7 +200,7 @@ func ValidateGitCommand(command string, args []string) error {
 		"diff":   true,
 		"status": true,
 		"push":   true,
-		"config": true,
+		"apply":  true,
 	}
 	
 	if !allowedCommands[command] {
@@ -213,8 +213,8 @@ func ValidateGitCommand(command string, args []string) error {
 		return validateDiffArgs(args)
 	case "push":
 		return validatePushArgs(args)
-	case "config":
-		return validateConfigArgs(args)
+	case "apply":
+		return validateApplyArgs(args)
 	}
 	
 	return nil
@@ -222,8 +222,12 @@ func ValidateGitCommand(command string, args []string) error {
 
 func validateDiffArgs(args []string) error {
 	allowedArgs := map[string]bool{
-		"--staged": true,
-		"--cached": true,
+		"--staged":     true,
+		"--cached":     true,
+		"--name-only":  true,
+		"--numstat":    true,
+		"--no-renames": true,
+		"--no-color":   true,
 	}
 	
 	for _, arg := range args {
@@ -238,13 +242,49 @@ func validateDiffArgs(args []string) error {
 	return nil
 }
 
+// validateApplyArgs only allows applying, or checking, a patch from stdin to the index
+func validateApplyArgs(args []string) error {
+	allowedArgs := map[string]bool{
+		"--cached":  true,
+		"--check":   true,
+		"--recount": true,
+		"-":         true,
+	}
+
+	for _, arg := range args {
+		if !allowedArgs[arg] {
+			return NewValidationError("git_apply_arg", arg, "git apply argument not allowed")
+		}
+	}
+	return nil
+}
+
+// validatePushArgs allows "git push [--set-upstream|-u] [--force-with-lease] <remote> <branch>",
+// where branch may also be "<branch>:refs/heads/<upstream>"
 func validatePushArgs(args []string) error {
-	if len(args) != 2 {
+	allowedFlags := map[string]bool{
+		"--set-upstream":     true,
+		"-u":                 true,
+		"--force-with-lease": true,
+	}
+
+	var positional []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			if !allowedFlags[arg] {
+				return NewValidationError("git_push_arg", arg, "git push argument not allowed")
+			}
+			continue
+		}
+		positional = append(positional, arg)
+	}
+
+	if len(positional) != 2 {
 		return NewValidationError("git_push_args", args, "git push requires exactly 2 arguments: remote and branch")
 	}
 	
-	remote := args[0]
-	branch := args[1]
+	remote := positional[0]
+	branch := positional[1]
 	
 	// Validate remote name (only alphanumeric, dash, underscore)
 	for _, r := range remote {
@@ -253,7 +293,26 @@ func validatePushArgs(args []string) error {
 		}
 	}
 	
-	// Validate branch name
+	// A refspec may only push the branch to another branch of the remote
+	if source, destination, ok := strings.Cut(branch, ":"); ok {
+		upstream := strings.TrimPrefix(destination, "refs/heads/")
+		if upstream == destination || source == "" || upstream == "" {
+			return NewValidationError("git_refspec", branch, "refspec must be <branch>:refs/heads/<branch>")
+		}
+		if err := validateBranchName(source); err != nil {
+			return err
+		}
+		return validateBranchName(upstream)
+	}
+
+	return validateBranchName(branch)
+}
+
+// validateBranchName only allows letters, digits and "-_/." in a branch name
+func validateBranchName(branch string) error {
+	if strings.HasPrefix(branch, "-") {
+		return NewValidationError("git_branch", branch, "branch name must not start with '-'")
+	}
 	for _, r := range branch {
 		if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '/' || r == '.') {
 			return NewValidationError("git_branch", branch, "invalid characters in branch name")
@@ -263,20 +322,37 @@ func validatePushArgs(args []string) error {
 	return nil
 }
 
-func validateConfigArgs(args []string) error {
-	if len(args) != 1 {
-		return NewValidationError("git_config_args", args, "git config requires exactly 1 argument")
+// ValidateSigningCommand validates the signing program and arguments used for commit signing
+func ValidateSigningCommand(program string, args []string) error {
+	if strings.TrimSpace(program) == "" {
+		return NewValidationError("signing_program", program, "signing program is empty")
 	}
-	
-	key := args[0]
-	allowedKeys := map[string]bool{
-		"user.name":  true,
-		"user.email": true,
+	if strings.ContainsAny(program, ";|&$`<>\n") {
+		return NewValidationError("signing_program", program, "potentially dangerous characters in signing program")
 	}
-	
-	if !allowedKeys[key] {
-		return NewValidationError("git_config_key", key, "git config key not allowed")
+
+	// Only the flags git itself passes to gpg, gpgsm and ssh-keygen are allowed
+	allowedFlags := map[string]bool{
+		"--status-fd=2": true,
+		"-bsau":         true,
+		"-Y":            true,
+		"-n":            true,
+		"-f":            true,
+		"-U":            true,
 	}
-	
+
+	for _, arg := range args {
+		// Keys and file names starting with "-" would be parsed as options, so they are rejected too
+		if strings.HasPrefix(arg, "-") {
+			if !allowedFlags[arg] {
+				return NewValidationError("signing_arg", arg, "signing argument not allowed")
+			}
+			continue
+		}
+		if strings.ContainsAny(arg, "\x00\n") {
+			return NewValidationError("signing_arg", arg, "invalid characters in signing argument")
+		}
+	}
+
 	return nil
 }
@@ -1,9 +1,83 @@
 package types
 
-// Message represents a chat message
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// Part types of a multimodal message
+const (
+	PartText  = "text"
+	PartImage = "image"
+)
+
+// Message represents a chat message. Messages with Parts are multimodal; their
+// Content holds the text parts for providers and logs that need plain text.
 type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	Parts   []Part `json:"-"`
+}
+
+// Part is a piece of a multimodal message: text, or an image with its MIME type
+// and base64-encoded data
+type Part struct {
+	Type     string
+	Text     string
+	MIMEType string
+	Data     string
+}
+
+// TextPart returns a text part
+func TextPart(text string) Part {
+	return Part{Type: PartText, Text: text}
+}
+
+// ImagePart returns an image part, e.g. for a PNG screenshot
+func ImagePart(mimeType string, data []byte) Part {
+	return Part{Type: PartImage, MIMEType: mimeType, Data: base64.StdEncoding.EncodeToString(data)}
+}
+
+// NewMessage creates a message from parts. A single text part gives a plain
+// text message.
+func NewMessage(role string, parts ...Part) Message {
+	var texts []string
+	for _, part := range parts {
+		if part.Type == PartText {
+			texts = append(texts, part.Text)
+		}
+	}
+	message := Message{Role: role, Content: strings.Join(texts, "\n\n")}
+	if len(texts) != len(parts) || len(parts) > 1 {
+		message.Parts = parts
+	}
+	return message
+}
+
+// MarshalJSON encodes multimodal messages in the OpenAI format, with content as a
+// list of parts and images as data URLs
+func (m Message) MarshalJSON() ([]byte, error) {
+	if len(m.Parts) == 0 {
+		type plain Message
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		Role    string `json:"role"`
+		Content []Part `json:"content"`
+	}{m.Role, m.Parts})
+}
+
+// MarshalJSON encodes a part as {"type": "text", "text": ...} or
+// {"type": "image_url", "image_url": {"url": "data:<mime>;base64,..."}}
+func (p Part) MarshalJSON() ([]byte, error) {
+	if p.Type == PartImage {
+		return json.Marshal(map[string]interface{}{
+			"type":      "image_url",
+			"image_url": map[string]string{"url": "data:" + p.MIMEType + ";base64," + p.Data},
+		})
+	}
+	return json.Marshal(map[string]string{"type": PartText, "text": p.Text})
 }
 
 // StreamCallback is called for each delta during streaming